@@ -0,0 +1,387 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const accountPurgeInterval = time.Hour
+
+// wallpaperKeyPrefix is the GeneratedS3 key prefix for a user's wallpapers
+func wallpaperKeyPrefix(userID uuid.UUID) string {
+	return "wallpaper_" + userID.String() + "_"
+}
+
+// errUnknownWallpaper is returned when an entry names a wallpaper the user
+// wasn't issued
+var errUnknownWallpaper = errors.New("Unknown wallpaper_s3_key")
+
+// ownedWallpaperKeys returns the GeneratedS3 objects that belong to userID:
+// everything under the user's prefix plus the wallpapers recorded in their
+// provenance. Keys their entries merely point at aren't trusted, since those
+// come from the client.
+func (ctrl *Controller) ownedWallpaperKeys(db *gorm.DB, userID uuid.UUID) ([]string, error) {
+	var generated []string
+	if err := db.Model(&models.PasswordProvenance{}).
+		Where("user_id = ? AND wallpaper_s3_key <> ''", userID).
+		Distinct().Pluck("wallpaper_s3_key", &generated).Error; err != nil {
+		return nil, err
+	}
+	prefixed, err := ctrl.GeneratedS3.ListKeys(wallpaperKeyPrefix(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	keys := make([]string, 0, len(prefixed)+len(generated))
+	for _, key := range append(prefixed, generated...) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// checkWallpaperKey accepts a wallpaper key for one of the user's entries
+// only if it is one generated for them
+func checkWallpaperKey(db *gorm.DB, userID uuid.UUID, key string) error {
+	if !strings.HasPrefix(key, wallpaperKeyPrefix(userID)) {
+		return errUnknownWallpaper
+	}
+	var count int64
+	if err := db.Model(&models.PasswordProvenance{}).
+		Where("user_id = ? AND wallpaper_s3_key = ?", userID, key).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errUnknownWallpaper
+	}
+	return nil
+}
+
+// HandleAccountExport streams a zip of everything we hold about the user:
+// profile, linked sign-in methods, entries (with secrets), groups, audit
+// history and wallpaper images.
+func (ctrl *Controller) HandleAccountExport(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var (
+		user       models.User
+		identities []models.UserIdentity
+		sessions   []models.Session
+		passkeys   []models.WebAuthnCredential
+		entries    []models.PasswordEntry
+		groups     []models.VaultGroup
+		events     []models.AuditEvent
+		accesses   []models.SecretAccess
+		provenance []models.PasswordProvenance
+		totp       models.UserTOTP
+		prefs      *models.UserPreferences
+	)
+	err := ctrl.DB.First(&user, "id = ?", userID).Error
+	queries := []struct {
+		dest  interface{}
+		scope *gorm.DB
+	}{
+		{&identities, ctrl.DB},
+		{&sessions, ctrl.DB},
+		{&passkeys, ctrl.DB},
+		// Include entries and groups in the trash
+		{&entries, ctrl.DB.Unscoped()},
+		{&groups, ctrl.DB.Unscoped()},
+		{&accesses, ctrl.DB},
+		{&provenance, ctrl.DB},
+	}
+	for _, q := range queries {
+		if err != nil {
+			break
+		}
+		err = q.scope.Where("user_id = ?", userID).Order("created_at asc").Find(q.dest).Error
+	}
+	if err == nil {
+		err = ctrl.DB.Where("actor_id = ?", userID).Order("seq asc").Find(&events).Error
+	}
+	if err == nil {
+		err = ctrl.DB.Where("user_id = ?", userID).Limit(1).Find(&totp).Error
+	}
+	if err == nil {
+		prefs, err = loadPreferences(ctrl.DB, userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to collect account data"})
+		return
+	}
+	wallpapers, err := ctrl.ownedWallpaperKeys(ctrl.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list wallpapers"})
+		return
+	}
+
+	if err := ctrl.recordSecretAccess(c, "export", nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record access"})
+		return
+	}
+	ctrl.audit(c, AuditAccountExport, models.AuditSuccess, "user", userID.String(), fmt.Sprintf("%d entries, %d wallpapers", len(entries), len(wallpapers)))
+
+	type ExportEntry struct {
+		models.PasswordEntry
+		Password  string     `json:"password"`
+		DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	}
+	exported := make([]ExportEntry, 0, len(entries))
+	for _, e := range entries {
+		item := ExportEntry{PasswordEntry: e, Password: e.Password}
+		if e.DeletedAt.Valid {
+			item.DeletedAt = &e.DeletedAt.Time
+		}
+		exported = append(exported, item)
+	}
+
+	// Never include the TOTP secret itself, only whether it is enabled
+	twoFactor := gin.H{"totp_enabled": totp.ConfirmedAt != nil, "confirmed_at": totp.ConfirmedAt}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", gin.H{"user": user, "two_factor": twoFactor, "preferences": prefs.Data}},
+		{"identities.json", identities},
+		{"sessions.json", sessions},
+		{"passkeys.json", passkeys},
+		{"entries.json", exported},
+		{"groups.json", groups},
+		{"audit_events.json", events},
+		{"secret_access.json", accesses},
+		{"provenance.json", provenance},
+	}
+
+	filename := fmt.Sprintf("lavalock-account-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	// The status is already sent, so failures from here on can only be logged
+	zw := zip.NewWriter(c.Writer)
+	defer zw.Close()
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			log.Printf("Account export: %v", err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			log.Printf("Account export: %v", err)
+			return
+		}
+	}
+	for _, key := range wallpapers {
+		data, err := ctrl.GeneratedS3.DownloadImage(key)
+		if err != nil {
+			log.Printf("Account export: skipping wallpaper %s: %v", key, err)
+			continue
+		}
+		w, err := zw.Create("wallpapers/" + path.Base(key))
+		if err != nil {
+			log.Printf("Account export: %v", err)
+			return
+		}
+		if _, err := w.Write(data); err != nil {
+			log.Printf("Account export: %v", err)
+			return
+		}
+	}
+}
+
+// HandleRequestAccountDeletion schedules the account for deletion after the
+// grace period and signs out every other device
+func (ctrl *Controller) HandleRequestAccountDeletion(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+	sessionIDInterface, _ := c.Get("session_id")
+	sessionID := sessionIDInterface.(uuid.UUID)
+
+	var user models.User
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.DeleteAfter != nil {
+			return nil
+		}
+		deleteAfter := time.Now().Add(ctrl.DeletionGrace)
+		if err := tx.Model(&user).Update("delete_after", &deleteAfter).Error; err != nil {
+			return err
+		}
+		user.DeleteAfter = &deleteAfter
+
+		var others []uuid.UUID
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, sessionID).
+			Pluck("id", &others).Error; err != nil {
+			return err
+		}
+		for _, id := range others {
+			if err := ctrl.revokeSession(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule deletion"})
+		return
+	}
+	ctrl.audit(c, AuditAccountDeleteRequest, models.AuditSuccess, "user", userID.String(), "delete after "+user.DeleteAfter.UTC().Format(time.RFC3339))
+
+	// Without a grace period there is nothing to wait for
+	if ctrl.DeletionGrace == 0 {
+		if err := ctrl.purgeAccount(userID); err != nil {
+			log.Printf("Account purge %s failed: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Account deleted", "deleted": true})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Account scheduled for deletion",
+		"delete_after": user.DeleteAfter,
+	})
+}
+
+// HandleCancelAccountDeletion cancels a pending deletion during the grace period
+func (ctrl *Controller) HandleCancelAccountDeletion(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	result := ctrl.DB.Model(&models.User{}).
+		Where("id = ? AND delete_after IS NOT NULL", userID).
+		Update("delete_after", nil)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No deletion pending"})
+		return
+	}
+	ctrl.audit(c, AuditAccountDeleteCancel, models.AuditSuccess, "user", userID.String(), "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deletion cancelled"})
+}
+
+// StartAccountPurgeLoop periodically purges accounts whose grace period is
+// over, along with provenance records for passwords that were never saved
+func (ctrl *Controller) StartAccountPurgeLoop() {
+	if ctrl.DB == nil {
+		log.Println("Account purge: no database, not starting")
+		return
+	}
+	go func() {
+		for ; ; time.Sleep(accountPurgeInterval) {
+			ctrl.sweepProvenance()
+
+			var due []uuid.UUID
+			if err := ctrl.DB.Model(&models.User{}).Where("delete_after <= ?", time.Now()).Pluck("id", &due).Error; err != nil {
+				log.Printf("Account purge: %v", err)
+				continue
+			}
+			for _, userID := range due {
+				if err := ctrl.purgeAccount(userID); err != nil {
+					log.Printf("Account purge %s failed: %v", userID, err)
+				}
+			}
+		}
+	}()
+}
+
+// userOwnedTables are hard-deleted by user_id when an account is purged
+var userOwnedTables = []interface{}{
+	&models.PasswordEntry{},
+	&models.VaultGroup{},
+	&models.RefreshToken{},
+	&models.Session{},
+	&models.UserIdentity{},
+	&models.WebAuthnCredential{},
+	&models.WebAuthnChallenge{},
+	&models.UserTOTP{},
+	&models.RecoveryCode{},
+	&models.SecretAccess{},
+	&models.UserPreferences{},
+	&models.PasswordProvenance{},
+}
+
+// purgeAccount deletes the user's wallpapers from GeneratedS3 and hard-deletes
+// their rows. Audit events are append-only and are kept; after the purge they
+// only reference a user ID that no longer exists.
+func (ctrl *Controller) purgeAccount(userID uuid.UUID) error {
+	var (
+		purged     bool
+		wallpapers int
+	)
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED so two instances never purge the same account at once
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND delete_after <= ?", userID, time.Now()).
+			Limit(1).Find(&user).Error; err != nil {
+			return err
+		}
+		if user.ID == uuid.Nil {
+			return nil // cancelled, or another instance has it
+		}
+
+		if ctrl.GeneratedS3 == nil {
+			return fmt.Errorf("generated bucket not configured")
+		}
+		keys, err := ctrl.ownedWallpaperKeys(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := ctrl.GeneratedS3.DeleteObjects(keys); err != nil {
+			return err
+		}
+		wallpapers = len(keys)
+
+		for _, model := range userOwnedTables {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		purged = true
+		return tx.Delete(&user).Error
+	})
+	if err != nil || !purged {
+		return err
+	}
+
+	if err := ctrl.Audit.Append(&models.AuditEvent{
+		Action:     AuditAccountPurge,
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Result:     models.AuditSuccess,
+		Detail:     fmt.Sprintf("%d wallpapers deleted", wallpapers),
+	}); err != nil {
+		log.Printf("Audit: failed to record %s for %s: %v", AuditAccountPurge, userID, err)
+	}
+	return nil
+}
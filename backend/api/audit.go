@@ -0,0 +1,225 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit actions
+const (
+	AuditLogin            = "auth.login"
+	AuditLogout           = "auth.logout"
+	AuditSecondFactor     = "auth.second_factor"
+	AuditTokenRefresh     = "auth.token_refresh"
+	AuditReauth           = "auth.reauth"
+	AuditLockout          = "auth.lockout"
+	AuditSessionRevoke    = "session.revoke"
+	AuditIdentityLink     = "identity.link"
+	AuditIdentityUnlink   = "identity.unlink"
+	AuditPasskeyRegister  = "passkey.register"
+	AuditPasskeyDelete    = "passkey.delete"
+	AuditTOTPEnable       = "totp.enable"
+	AuditTOTPDisable      = "totp.disable"
+	AuditRecoveryCodes    = "totp.recovery_codes"
+	AuditPasswordGenerate = "password.generate"
+	AuditPasswordCreate   = "password.create"
+	AuditPasswordDelete   = "password.delete"
+	AuditPasswordReveal   = "password.reveal"
+	AuditPasswordMove     = "password.move"
+	AuditPasswordBulk     = "password.bulk"
+	AuditPasswordVerify   = "password.verify_provenance"
+	AuditVaultExport      = "vault.export"
+	AuditVaultSync        = "vault.sync"
+	AuditGroupCreate      = "group.create"
+	AuditGroupDelete      = "group.delete"
+	AuditMFAView          = "mfa.view"
+
+	AuditAccountExport        = "account.export"
+	AuditAccountDeleteRequest = "account.delete_request"
+	AuditAccountDeleteCancel  = "account.delete_cancel"
+	AuditAccountPurge         = "account.purge"
+
+	AuditDeviceProvision = "device.provision"
+	AuditDeviceRotate    = "device.rotate_key"
+	AuditDeviceDisable   = "device.disable"
+	AuditDeviceEnroll    = "device.enroll"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// audit records an action by the authenticated user in the current request
+func (ctrl *Controller) audit(c *gin.Context, action, result, targetType, targetID, detail string) {
+	var actorID *uuid.UUID
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(uuid.UUID); ok {
+			actorID = &id
+		}
+	}
+	ctrl.auditAs(c, actorID, action, result, targetType, targetID, detail)
+}
+
+// auditAs is audit for requests where the actor isn't in the context yet
+// (logins) or is unknown (nil, e.g. a rejected ID token)
+func (ctrl *Controller) auditAs(c *gin.Context, actorID *uuid.UUID, action, result, targetType, targetID, detail string) {
+	event := &models.AuditEvent{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Result:     result,
+		Detail:     detail,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if v, ok := c.Get("session_id"); ok {
+		if id, ok := v.(uuid.UUID); ok {
+			event.SessionID = &id
+		}
+	}
+	// Auditing never fails the request, but a gap must be visible in the logs
+	if err := ctrl.Audit.Append(event); err != nil {
+		log.Printf("Audit: failed to record %s for %v: %v", action, actorID, err)
+	}
+}
+
+// auditResult maps an error to success/failure
+func auditResult(err error) string {
+	if err != nil {
+		return models.AuditFailure
+	}
+	return models.AuditSuccess
+}
+
+// AdminMiddleware only admits users with the admin role. Must run after AuthMiddleware.
+func (ctrl *Controller) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDInterface, _ := c.Get("user_id")
+		var user models.User
+		if err := ctrl.DB.First(&user, "id = ?", userIDInterface).Error; err != nil || user.Role != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// filterAudit applies the query filters shared by the user and admin views:
+// action (a trailing * matches a prefix, e.g. password.*), result,
+// target_type, target_id, since/until (RFC 3339) and before (seq cursor)
+func filterAudit(c *gin.Context, query *gorm.DB) (*gorm.DB, int, bool) {
+	if action := c.Query("action"); action != "" {
+		if prefix, ok := strings.CutSuffix(action, "*"); ok {
+			query = query.Where("action LIKE ?", prefix+"%")
+		} else {
+			query = query.Where("action = ?", action)
+		}
+	}
+	for _, field := range []string{"result", "target_type", "target_id"} {
+		if v := c.Query(field); v != "" {
+			query = query.Where(field+" = ?", v)
+		}
+	}
+	for param, op := range map[string]string{"since": ">=", "until": "<"} {
+		if raw := c.Query(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " (expected RFC 3339)"})
+				return nil, 0, false
+			}
+			query = query.Where("created_at "+op+" ?", t)
+		}
+	}
+	if raw := c.Query("before"); raw != "" {
+		before, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before cursor"})
+			return nil, 0, false
+		}
+		query = query.Where("seq < ?", before)
+	}
+
+	limit := defaultAuditPageSize
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return nil, 0, false
+		}
+		limit = min(n, maxAuditPageSize)
+	}
+	return query, limit, true
+}
+
+// respondAuditPage runs the query newest-first and returns a page plus the
+// cursor for the next (older) page
+func respondAuditPage(c *gin.Context, query *gorm.DB, limit int) {
+	var events []models.AuditEvent
+	if err := query.Order("seq desc").Limit(limit + 1).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit events"})
+		return
+	}
+
+	var next *int64
+	if len(events) > limit {
+		events = events[:limit]
+		seq := events[limit-1].Seq
+		next = &seq
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"events":      events,
+		"next_before": next,
+	})
+}
+
+// HandleListAudit returns the current user's own audit events
+func (ctrl *Controller) HandleListAudit(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	query, limit, ok := filterAudit(c, ctrl.DB.Model(&models.AuditEvent{}).Where("actor_id = ?", userID))
+	if !ok {
+		return
+	}
+	respondAuditPage(c, query, limit)
+}
+
+// HandleAdminListAudit returns audit events for every user. Also filters by actor_id.
+func (ctrl *Controller) HandleAdminListAudit(c *gin.Context) {
+	query := ctrl.DB.Model(&models.AuditEvent{})
+	if raw := c.Query("actor_id"); raw != "" {
+		actorID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor_id"})
+			return
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+
+	query, limit, ok := filterAudit(c, query)
+	if !ok {
+		return
+	}
+	respondAuditPage(c, query, limit)
+}
+
+// HandleAdminVerifyAudit walks the hash chain and reports the first broken link
+func (ctrl *Controller) HandleAdminVerifyAudit(c *gin.Context) {
+	result, err := ctrl.Audit.Verify(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -1,109 +1,97 @@
 package api
 
 import (
-	"context"
 	"net/http"
-	"os"
 
 	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
-	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"google.golang.org/api/idtoken"
 )
 
-// GoogleAuthRequest defines the payload for Google Sign-In
+// GoogleAuthRequest defines the payload for Google (or any OIDC provider) Sign-In
 type GoogleAuthRequest struct {
-	IDToken string `json:"id_token" binding:"required"`
+	IDToken    string `json:"id_token" binding:"required"`
+	DeviceName string `json:"device_name"` // Optional, shown in the session list
 }
 
 // HandleGoogleLogin verifies the ID token from the client and creates a session
 func (ctrl *Controller) HandleGoogleLogin(c *gin.Context) {
+	ctrl.loginWithProvider(c, "google")
+}
+
+// HandleOIDCLogin is HandleGoogleLogin for any configured provider (/auth/oidc/:provider)
+func (ctrl *Controller) HandleOIDCLogin(c *gin.Context) {
+	ctrl.loginWithProvider(c, c.Param("provider"))
+}
+
+func (ctrl *Controller) loginWithProvider(c *gin.Context, provider string) {
 	var req GoogleAuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id_token required"})
 		return
 	}
 
-	clientID := os.Getenv("GOOGLE_CLIENT_ID")
-	if clientID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server misconfiguration: GOOGLE_CLIENT_ID missing"})
+	user, ok := ctrl.verifyIdentity(c, provider, req.IDToken)
+	if !ok {
+		ctrl.auditAs(c, nil, AuditLogin, models.AuditFailure, "identity", provider, "")
 		return
 	}
 
-	// Verify the Token with Google
-	payload, err := idtoken.Validate(context.Background(), req.IDToken, clientID)
+	// Create Session
+	record, err := ctrl.startSession(c, user.ID, req.DeviceName)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
 		return
 	}
-
-	// Extract User Info
-	googleID := payload.Subject
-	email, _ := payload.Claims["email"].(string)
-	name, _ := payload.Claims["name"].(string)
-
-	// Find or Create User
-	var user models.User
-	result := ctrl.DB.Where(&models.User{GoogleID: googleID}).First(&user)
-	if result.Error != nil {
-		// Create new user with explicit UUID
-		user = models.User{
-			ID:       uuid.New(),
-			GoogleID: googleID,
-			Email:    email,
-			Name:     name,
-		}
-		if err := ctrl.DB.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-			return
-		}
-	} else {
-		// Update user info
-		user.Email = email
-		user.Name = name
-		ctrl.DB.Save(&user)
-	}
-
-	// Create Session
-	session := sessions.Default(c)
-	// Store UUID string in session to be safe with gob serialization
-	session.Set("user_id", user.ID.String())
-	session.Save()
+	ctrl.auditAs(c, &user.ID, AuditLogin, models.AuditSuccess, "session", record.ID.String(), provider)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user,
+		"message":                "Login successful",
+		"user":                   user,
+		"second_factor_required": record.SecondFactorPending,
 	})
 }
 
+// AuthMiddleware validates the session cookie against the session store on every request.
+// A mobile or CLI client may instead send "Authorization: Bearer <access token>".
+// Sessions still waiting for a second factor are rejected.
 func (ctrl *Controller) AuthMiddleware() gin.HandlerFunc {
+	return ctrl.authenticate(false)
+}
+
+// PendingAuthMiddleware is AuthMiddleware but also admits sessions waiting for
+// a second factor; only the verification and logout routes use it.
+func (ctrl *Controller) PendingAuthMiddleware() gin.HandlerFunc {
+	return ctrl.authenticate(true)
+}
+
+func (ctrl *Controller) authenticate(allowPending bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		userIDStr := session.Get("user_id")
-		if userIDStr == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
-			return
+		var (
+			record *models.Session
+			ok     bool
+		)
+		if token, isBearer := bearerToken(c); isBearer {
+			record, ok = ctrl.loadBearerSession(c, token)
+		} else {
+			record, ok = ctrl.loadSession(c)
 		}
-
-		// Parse UUID
-		idStr, ok := userIDStr.(string)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session data"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-
-		userID, err := uuid.Parse(idStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid User ID"})
+		if record.SecondFactorPending && !allowPending {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":                  "Second factor required",
+				"second_factor_required": true,
+			})
 			c.Abort()
 			return
 		}
 
 		// Add UserID to context for handlers to use
-		c.Set("user_id", userID)
+		c.Set("user_id", record.UserID)
+		c.Set("session_id", record.ID)
 		c.Next()
 	}
 }
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBeaconVerifyRange bounds how many pulses one public verify call walks
+const maxBeaconVerifyRange = 10000
+
+// StartBeaconLoop publishes a pulse at the start of every beacon period
+func (ctrl *Controller) StartBeaconLoop() {
+	period := ctrl.Beacon.Period()
+	go func() {
+		for {
+			now := time.Now()
+			time.Sleep(now.Truncate(period).Add(period).Sub(now))
+
+			healthy := ctrl.Health == nil || ctrl.Health.Ready() == nil
+			pulse, err := ctrl.Beacon.Emit(context.Background(), healthy)
+			if err != nil {
+				log.Printf("Beacon: failed to emit pulse: %v", err)
+				continue
+			}
+			if pulse != nil && pulse.Status&models.PulseChainStart != 0 {
+				log.Printf("Beacon: chain (re)started at pulse %d", pulse.Index)
+			}
+		}
+	}()
+}
+
+// beaconEnabled responds 404 when the beacon isn't configured
+func (ctrl *Controller) beaconEnabled(c *gin.Context) bool {
+	if ctrl.Beacon == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Beacon is not enabled"})
+		return false
+	}
+	return true
+}
+
+func respondPulse(c *gin.Context, query func(*models.BeaconPulse) (int64, error)) {
+	var pulse models.BeaconPulse
+	found, err := query(&pulse)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pulse"})
+		return
+	}
+	if found == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pulse not found"})
+		return
+	}
+	c.JSON(http.StatusOK, pulse)
+}
+
+// HandleBeaconLast returns the most recent pulse
+func (ctrl *Controller) HandleBeaconLast(c *gin.Context) {
+	if !ctrl.beaconEnabled(c) {
+		return
+	}
+	respondPulse(c, func(p *models.BeaconPulse) (int64, error) {
+		res := ctrl.DB.Order("pulse_index desc").Limit(1).Find(p)
+		return res.RowsAffected, res.Error
+	})
+}
+
+// HandleBeaconByIndex returns the pulse with the given index
+func (ctrl *Controller) HandleBeaconByIndex(c *gin.Context) {
+	if !ctrl.beaconEnabled(c) {
+		return
+	}
+	index, err := strconv.ParseInt(c.Param("index"), 10, 64)
+	if err != nil || index <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pulse index"})
+		return
+	}
+	respondPulse(c, func(p *models.BeaconPulse) (int64, error) {
+		res := ctrl.DB.Where("pulse_index = ?", index).Limit(1).Find(p)
+		return res.RowsAffected, res.Error
+	})
+}
+
+// HandleBeaconByTime returns the pulse in effect at the given time (RFC 3339
+// or unix milliseconds): the latest pulse at or before it
+func (ctrl *Controller) HandleBeaconByTime(c *gin.Context) {
+	if !ctrl.beaconEnabled(c) {
+		return
+	}
+	raw := c.Param("time")
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		ms, msErr := strconv.ParseInt(raw, 10, 64)
+		if msErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time (expected RFC 3339 or unix milliseconds)"})
+			return
+		}
+		at = time.UnixMilli(ms)
+	}
+	respondPulse(c, func(p *models.BeaconPulse) (int64, error) {
+		res := ctrl.DB.Where("time_stamp <= ?", at).Order("time_stamp desc").Limit(1).Find(p)
+		return res.RowsAffected, res.Error
+	})
+}
+
+// HandleBeaconVerify checks the signatures and hash links of pulses from..to
+// (query parameters; by default the latest 1000 pulses)
+func (ctrl *Controller) HandleBeaconVerify(c *gin.Context) {
+	if !ctrl.beaconEnabled(c) {
+		return
+	}
+	var last models.BeaconPulse
+	if err := ctrl.DB.Order("pulse_index desc").Limit(1).Find(&last).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pulses"})
+		return
+	}
+
+	to := last.Index
+	if raw := c.Query("to"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to"})
+			return
+		}
+		to = min(n, last.Index)
+	}
+	from := max(to-999, 1)
+	if raw := c.Query("from"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 || n > to {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from"})
+			return
+		}
+		from = n
+	}
+	if to-from+1 > maxBeaconVerifyRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Range too large (max 10000 pulses)"})
+		return
+	}
+	if to == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pulses yet"})
+		return
+	}
+
+	result, err := ctrl.Beacon.VerifyChain(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify beacon chain"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
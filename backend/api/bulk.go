@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Supported bulk operation types
+const (
+	BulkOpDelete   = "delete"
+	BulkOpMove     = "move"
+	BulkOpTag      = "tag"
+	BulkOpFavorite = "favorite"
+	BulkOpRestore  = "restore"
+)
+
+const maxBulkOperations = 500
+
+// BulkOperation is a single action against one vault entry
+type BulkOperation struct {
+	Op       string     `json:"op"`
+	ID       uuid.UUID  `json:"id"`
+	GroupID  *uuid.UUID `json:"group_id"` // move
+	Tags     []string   `json:"tags"`     // tag (replaces existing tags)
+	Favorite *bool      `json:"favorite"` // favorite
+}
+
+// BulkRequest is the body of POST /api/passwords/bulk.
+// When Atomic is true, any failed operation rolls back the whole batch.
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkResult reports the outcome of one operation
+type BulkResult struct {
+	Index int       `json:"index"`
+	Op    string    `json:"op"`
+	ID    uuid.UUID `json:"id"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+}
+
+var errBulkRolledBack = errors.New("bulk operation rolled back")
+
+// HandleBulkPasswords executes a list of operations in a single transaction
+func (ctrl *Controller) HandleBulkPasswords(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operations required"})
+		return
+	}
+	if len(req.Operations) > maxBulkOperations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d operations per request", maxBulkOperations)})
+		return
+	}
+
+	// Filled in up front so an atomic batch that stops early still reports
+	// which operation each result belongs to
+	results := make([]BulkResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = BulkResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+	failed := 0
+	attempted := 0
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		for i, op := range req.Operations {
+			attempted = i + 1
+
+			// Postgres aborts the whole transaction on a failed statement,
+			// so each operation runs behind its own savepoint.
+			savepoint := fmt.Sprintf("bulk_op_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			if err := applyBulkOperation(tx, userID, op); err != nil {
+				failed++
+				results[i].Error = err.Error()
+				if req.Atomic {
+					return errBulkRolledBack
+				}
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			results[i].OK = true
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errBulkRolledBack):
+		ctrl.audit(c, AuditPasswordBulk, models.AuditFailure, "password", "", fmt.Sprintf("%d operations rolled back", len(results)))
+		// Nothing was committed, so report every operation as not applied
+		for i := range results {
+			results[i].OK = false
+			if i >= attempted {
+				results[i].Error = "not attempted"
+			}
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Batch rolled back",
+			"atomic":  true,
+			"results": results,
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply bulk operations"})
+	default:
+		if failed < len(results) {
+			ids := make([]uuid.UUID, 0, len(results))
+			for _, r := range results {
+				if r.OK {
+					ids = append(ids, r.ID)
+				}
+			}
+			ctrl.notifyVaultChanged(userID, "bulk", ids...)
+		}
+		ctrl.audit(c, AuditPasswordBulk, models.AuditSuccess, "password", "", fmt.Sprintf("%d succeeded, %d failed", len(results)-failed, failed))
+		c.JSON(http.StatusOK, gin.H{
+			"atomic":    req.Atomic,
+			"succeeded": len(results) - failed,
+			"failed":    failed,
+			"results":   results,
+		})
+	}
+}
+
+// applyBulkOperation runs a single operation inside tx.
+// Returned errors are safe to show to the client.
+func applyBulkOperation(tx *gorm.DB, userID uuid.UUID, op BulkOperation) error {
+	var entry models.PasswordEntry
+	if err := tx.Unscoped().Where("id = ? AND user_id = ?", op.ID, userID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("entry not found")
+		}
+		return errors.New("failed to load entry")
+	}
+
+	deleted := entry.DeletedAt.Valid
+	if deleted && op.Op != BulkOpRestore {
+		return errors.New("entry is deleted")
+	}
+
+	switch op.Op {
+	case BulkOpDelete:
+		if err := tx.Delete(&entry).Error; err != nil {
+			return errors.New("failed to delete entry")
+		}
+
+	case BulkOpRestore:
+		if !deleted {
+			return errors.New("entry is not deleted")
+		}
+		if err := tx.Unscoped().Model(&entry).Update("deleted_at", nil).Error; err != nil {
+			return errors.New("failed to restore entry")
+		}
+
+	case BulkOpMove:
+		if err := validateGroupID(tx, userID, op.GroupID); err != nil {
+			if errors.Is(err, ErrGroupNotFound) {
+				return errors.New("invalid group_id")
+			}
+			return errors.New("failed to verify group")
+		}
+		if err := tx.Model(&entry).Update("group_id", op.GroupID).Error; err != nil {
+			return errors.New("failed to move entry")
+		}
+
+	case BulkOpTag:
+		if err := tx.Model(&entry).Update("tags", normalizeTags(op.Tags)).Error; err != nil {
+			return errors.New("failed to tag entry")
+		}
+
+	case BulkOpFavorite:
+		if op.Favorite == nil {
+			return errors.New("favorite required")
+		}
+		if err := tx.Model(&entry).Update("favorite", *op.Favorite).Error; err != nil {
+			return errors.New("failed to update favorite")
+		}
+
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	return nil
+}
+
+// normalizeTags trims, drops empties and de-duplicates tags while keeping order
+func normalizeTags(tags []string) models.StringList {
+	seen := make(map[string]bool, len(tags))
+	out := models.StringList{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
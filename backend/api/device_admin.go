@@ -0,0 +1,262 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// enrollmentTTL is how long a provisioning token stays usable
+	enrollmentTTL  = 24 * time.Hour
+	deviceKeyBytes = 32
+
+	defaultFramePageSize = 50
+	maxFramePageSize     = 500
+)
+
+var errEnrollmentInvalid = errors.New("invalid enrollment token")
+
+// issueEnrollment gives the device a fresh one-time enrollment token and
+// returns it. Only the hash is stored, so it can't be shown again.
+func issueEnrollment(device *models.Device) (string, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(enrollmentTTL)
+	device.EnrollmentHash = hashToken(token)
+	device.EnrollmentExpiresAt = &expires
+	return token, nil
+}
+
+// loadDevice fetches the device named by the :id route parameter
+func (ctrl *Controller) loadDevice(c *gin.Context) (*models.Device, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Device ID format"})
+		return nil, false
+	}
+	var device models.Device
+	if err := ctrl.DB.First(&device, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return nil, false
+	}
+	return &device, true
+}
+
+// HandleAdminListDevices lists every registered camera
+func (ctrl *Controller) HandleAdminListDevices(c *gin.Context) {
+	var devices []models.Device
+	if err := ctrl.DB.Order("name").Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch devices"})
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// HandleAdminProvisionDevice registers a new camera and returns its one-time
+// enrollment token, which is flashed into the device's setup
+func (ctrl *Controller) HandleAdminProvisionDevice(c *gin.Context) {
+	type ProvisionRequest struct {
+		Name     string `json:"name" binding:"required"`
+		Location string `json:"location"`
+	}
+	var req ProvisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxNameLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be 1-100 characters"})
+		return
+	}
+
+	device := models.Device{
+		Name:     name,
+		Location: strings.TrimSpace(req.Location),
+		Status:   models.DeviceStatusPending,
+	}
+	token, err := issueEnrollment(&device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue enrollment token"})
+		return
+	}
+
+	var taken int64
+	ctrl.DB.Model(&models.Device{}).Where("name = ?", name).Count(&taken)
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A device with that name already exists"})
+		return
+	}
+	if err := ctrl.DB.Create(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create device"})
+		return
+	}
+
+	ctrl.audit(c, AuditDeviceProvision, models.AuditSuccess, "device", device.ID.String(), device.Name)
+	c.JSON(http.StatusCreated, gin.H{
+		"device":           device,
+		"enrollment_token": token,
+	})
+}
+
+// HandleAdminRotateDeviceKey issues a new enrollment token. The current key
+// keeps working until the device enrolls with it, so a rotation doesn't drop
+// frames; disable the device first if the key is compromised.
+func (ctrl *Controller) HandleAdminRotateDeviceKey(c *gin.Context) {
+	device, ok := ctrl.loadDevice(c)
+	if !ok {
+		return
+	}
+	token, err := issueEnrollment(device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue enrollment token"})
+		return
+	}
+	if err := ctrl.DB.Model(device).Updates(map[string]interface{}{
+		"enrollment_hash":       device.EnrollmentHash,
+		"enrollment_expires_at": device.EnrollmentExpiresAt,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate key"})
+		return
+	}
+
+	ctrl.audit(c, AuditDeviceRotate, models.AuditSuccess, "device", device.ID.String(), "")
+	c.JSON(http.StatusOK, gin.H{
+		"device":           device,
+		"enrollment_token": token,
+	})
+}
+
+// HandleAdminDisableDevice stops a camera from uploading and drops its frames
+// from the entropy pool. Rotating its key lets it enroll again.
+func (ctrl *Controller) HandleAdminDisableDevice(c *gin.Context) {
+	device, ok := ctrl.loadDevice(c)
+	if !ok {
+		return
+	}
+	if err := ctrl.DB.Model(device).Updates(map[string]interface{}{
+		"status":                models.DeviceStatusDisabled,
+		"enrollment_hash":       "",
+		"enrollment_expires_at": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable device"})
+		return
+	}
+	device.Status = models.DeviceStatusDisabled
+	device.EnrollmentExpiresAt = nil
+	ctrl.Entropy.Remove(device.ID)
+
+	ctrl.audit(c, AuditDeviceDisable, models.AuditSuccess, "device", device.ID.String(), "")
+	c.JSON(http.StatusOK, device)
+}
+
+// HandleAdminListDeviceFrames returns a device's most recent frames, newest
+// first. Accepts limit and before (RFC 3339 capture time) for paging.
+func (ctrl *Controller) HandleAdminListDeviceFrames(c *gin.Context) {
+	device, ok := ctrl.loadDevice(c)
+	if !ok {
+		return
+	}
+
+	query := ctrl.DB.Where("device_id = ?", device.ID)
+	if raw := c.Query("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before (expected RFC 3339)"})
+			return
+		}
+		query = query.Where("captured_at < ?", before)
+	}
+	limit := defaultFramePageSize
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxFramePageSize)
+	}
+
+	var frames []models.Frame
+	if err := query.Order("captured_at desc").Limit(limit).Find(&frames).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch frames"})
+		return
+	}
+	c.JSON(http.StatusOK, frames)
+}
+
+// HandleEnrollDevice exchanges a one-time enrollment token for the device's
+// HMAC key. The key is only ever returned here.
+func (ctrl *Controller) HandleEnrollDevice(c *gin.Context) {
+	type EnrollRequest struct {
+		DeviceID        uuid.UUID `json:"device_id" binding:"required"`
+		Token           string    `json:"enrollment_token" binding:"required"`
+		FirmwareVersion string    `json:"firmware_version"`
+	}
+	var req EnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_id and enrollment_token are required"})
+		return
+	}
+
+	key := make([]byte, deviceKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate key"})
+		return
+	}
+
+	firmware := strings.TrimSpace(req.FirmwareVersion)
+	var device models.Device
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&device, "id = ?", req.DeviceID).Error; err != nil {
+			return errEnrollmentInvalid
+		}
+		if device.EnrollmentHash == "" || device.EnrollmentExpiresAt == nil ||
+			time.Now().After(*device.EnrollmentExpiresAt) ||
+			subtle.ConstantTimeCompare([]byte(device.EnrollmentHash), []byte(hashToken(req.Token))) != 1 {
+			return errEnrollmentInvalid
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"key":                   key,
+			"key_rotated_at":        &now,
+			"status":                models.DeviceStatusActive,
+			"enrollment_hash":       "",
+			"enrollment_expires_at": nil,
+		}
+		if firmware != "" {
+			updates["firmware_version"] = firmware
+		}
+		return tx.Model(&device).Updates(updates).Error
+	})
+	if errors.Is(err, errEnrollmentInvalid) {
+		ctrl.auditAs(c, nil, AuditDeviceEnroll, models.AuditFailure, "device", req.DeviceID.String(), "")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired enrollment token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll device"})
+		return
+	}
+
+	ctrl.auditAs(c, nil, AuditDeviceEnroll, models.AuditSuccess, "device", device.ID.String(), firmware)
+	c.JSON(http.StatusOK, gin.H{
+		"device_id": device.ID,
+		"key":       base64.StdEncoding.EncodeToString(key),
+	})
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// MaxHeartbeatBytes caps the size of a heartbeat body
+const MaxHeartbeatBytes = 4 << 10
+
+// deviceHealthState grades a heartbeat. Failed captures and a drifting clock
+// (which breaks request signing) both degrade a device.
+func (ctrl *Controller) deviceHealthState(h *models.DeviceHealth) string {
+	skew := time.Duration(h.ClockSkewMs) * time.Millisecond
+	if h.LastCapture == models.CaptureFailed || skew > ctrl.DeviceConfig.MaxClockSkew || skew < -ctrl.DeviceConfig.MaxClockSkew {
+		return models.DeviceDegraded
+	}
+	return models.DeviceHealthy
+}
+
+// HandleDeviceHeartbeat records a camera's vitals. The response carries the
+// server time so the device can correct its clock.
+func (ctrl *Controller) HandleDeviceHeartbeat(c *gin.Context) {
+	deviceIDInterface, _ := c.Get("device_id")
+	deviceID := deviceIDInterface.(uuid.UUID)
+	bodyInterface, _ := c.Get("device_body")
+
+	type HeartbeatRequest struct {
+		UptimeSeconds    int64  `json:"uptime_seconds"`
+		RSSI             int    `json:"rssi"`
+		FreeHeap         int64  `json:"free_heap"`
+		LastCapture      string `json:"last_capture"`
+		LastCaptureError string `json:"last_capture_error"`
+		DeviceTime       int64  `json:"device_time"` // unix milliseconds
+	}
+	var req HeartbeatRequest
+	if err := json.Unmarshal(bodyInterface.([]byte), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid heartbeat body"})
+		return
+	}
+	switch req.LastCapture {
+	case "":
+		req.LastCapture = models.CapturePending
+	case models.CaptureOK, models.CaptureFailed, models.CapturePending:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "last_capture must be ok, failed or pending"})
+		return
+	}
+
+	now := time.Now()
+	// Without device_time, fall back to the (second-resolution) signed timestamp
+	deviceTime := req.DeviceTime
+	if deviceTime == 0 {
+		unix, _ := strconv.ParseInt(c.GetHeader("X-Timestamp"), 10, 64)
+		deviceTime = unix * 1000
+	}
+
+	health := models.DeviceHealth{
+		DeviceID:         deviceID,
+		ReceivedAt:       now,
+		UptimeSeconds:    req.UptimeSeconds,
+		RSSI:             req.RSSI,
+		FreeHeap:         req.FreeHeap,
+		LastCapture:      req.LastCapture,
+		LastCaptureError: req.LastCaptureError,
+		ClockSkewMs:      deviceTime - now.UnixMilli(),
+	}
+	if len(health.LastCaptureError) > 200 {
+		health.LastCaptureError = health.LastCaptureError[:200]
+	}
+	health.State = ctrl.deviceHealthState(&health)
+
+	var previous models.DeviceHealth
+	ctrl.DB.Where("device_id = ?", deviceID).Limit(1).Find(&previous)
+	if err := ctrl.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&health).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record heartbeat"})
+		return
+	}
+	if previous.State != "" && previous.State != health.State {
+		log.Printf("Device %s: %s -> %s", deviceID, previous.State, health.State)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"state":         health.State,
+		"server_time":   now.UnixMilli(),
+		"clock_skew_ms": health.ClockSkewMs,
+	})
+}
+
+// StartDeviceMonitor marks active devices stale once they miss heartbeats
+// for longer than DeviceConfig.StaleAfter
+func (ctrl *Controller) StartDeviceMonitor() {
+	if ctrl.DB == nil {
+		log.Println("Device monitor: no database, not starting")
+		return
+	}
+	interval := max(ctrl.DeviceConfig.StaleAfter/2, 15*time.Second)
+	go func() {
+		for ; ; time.Sleep(interval) {
+			cutoff := time.Now().Add(-ctrl.DeviceConfig.StaleAfter)
+			var stale []models.DeviceHealth
+			err := ctrl.DB.Model(&stale).
+				Clauses(clause.Returning{Columns: []clause.Column{{Name: "device_id"}}}).
+				Where("state <> ? AND received_at < ?", models.DeviceStale, cutoff).
+				Where("device_id IN (?)", ctrl.DB.Model(&models.Device{}).Select("id").Where("status = ?", models.DeviceStatusActive)).
+				Update("state", models.DeviceStale).Error
+			if err != nil {
+				log.Printf("Device monitor: %v", err)
+				continue
+			}
+			for _, h := range stale {
+				log.Printf("Device %s: no heartbeat since %s, marked stale", h.DeviceID, cutoff.Format(time.RFC3339))
+			}
+		}
+	}()
+}
+
+// HandleAdminFleetHealth summarises the health of every camera. Devices that
+// never sent a heartbeat are "unknown"; disabled devices are counted apart.
+func (ctrl *Controller) HandleAdminFleetHealth(c *gin.Context) {
+	var devices []models.Device
+	if err := ctrl.DB.Order("name").Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch devices"})
+		return
+	}
+	var rows []models.DeviceHealth
+	if err := ctrl.DB.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch device health"})
+		return
+	}
+	byDevice := make(map[uuid.UUID]*models.DeviceHealth, len(rows))
+	for i := range rows {
+		byDevice[rows[i].DeviceID] = &rows[i]
+	}
+
+	type DeviceStatus struct {
+		Device models.Device        `json:"device"`
+		State  string               `json:"state"`
+		Health *models.DeviceHealth `json:"health"`
+	}
+	summary := map[string]int{
+		models.DeviceHealthy:        0,
+		models.DeviceDegraded:       0,
+		models.DeviceStale:          0,
+		"unknown":                   0,
+		models.DeviceStatusDisabled: 0,
+		models.DeviceStatusPending:  0,
+	}
+	statuses := make([]DeviceStatus, 0, len(devices))
+	for _, d := range devices {
+		s := DeviceStatus{Device: d, State: "unknown", Health: byDevice[d.ID]}
+		switch {
+		case d.Status != models.DeviceStatusActive:
+			s.State = d.Status
+		case s.Health != nil:
+			s.State = s.Health.State
+		}
+		summary[s.State]++
+		statuses = append(statuses, s)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":       len(devices),
+		"summary":     summary,
+		"stale_after": ctrl.DeviceConfig.StaleAfter.String(),
+		"devices":     statuses,
+	})
+}
@@ -0,0 +1,414 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	deviceAuthScheme = "LavaHMAC"
+	deviceMaxSkew    = 5 * time.Minute
+	// maxFrameDelay is how long a camera may buffer a frame before uploading it
+	maxFrameDelay = time.Hour
+	// poolFrameMaxAge is how fresh a pooled frame must be to skip S3
+	poolFrameMaxAge = 5 * time.Minute
+)
+
+// MaxFrameBytes caps the size of an uploaded frame
+const MaxFrameBytes = 5 << 20
+
+var jpegMagic = []byte{0xFF, 0xD8, 0xFF}
+
+// deviceSignature is the signature a camera sends with each request:
+//
+//	hex(HMAC-SHA256(key, METHOD "\n" PATH "\n" TIMESTAMP "\n" hex(SHA256(body))))
+//
+// Cameras send it as "Authorization: LavaHMAC <signature>" together with
+// X-Device-ID (the device UUID) and X-Timestamp (unix seconds). An optional
+// X-Firmware-Version header keeps the registry's firmware version current.
+func deviceSignature(key []byte, method, path, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeviceAuthMiddleware verifies a signed camera request. The body (at most
+// maxBody bytes) is read here, so handlers get it from "device_body".
+func (ctrl *Controller) DeviceAuthMiddleware(maxBody int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reject := func() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid device signature"})
+			c.Abort()
+		}
+
+		deviceID, err := uuid.Parse(c.GetHeader("X-Device-ID"))
+		if err != nil {
+			reject()
+			return
+		}
+		timestamp := c.GetHeader("X-Timestamp")
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			reject()
+			return
+		}
+		if skew := time.Since(time.Unix(unix, 0)); skew > deviceMaxSkew || skew < -deviceMaxSkew {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Request timestamp outside the allowed clock skew"})
+			c.Abort()
+			return
+		}
+		signature, ok := strings.CutPrefix(c.GetHeader("Authorization"), deviceAuthScheme+" ")
+		if !ok {
+			reject()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Body larger than %d bytes", maxBody)})
+			c.Abort()
+			return
+		}
+
+		var device models.Device
+		if err := ctrl.DB.First(&device, "id = ?", deviceID).Error; err != nil || len(device.Key) == 0 {
+			reject()
+			return
+		}
+		expected := deviceSignature(device.Key, c.Request.Method, c.Request.URL.Path, timestamp, body)
+		if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+			reject()
+			return
+		}
+
+		if device.Status != models.DeviceStatusActive {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Device is " + device.Status})
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"last_seen_at": &now}
+		if fw := c.GetHeader("X-Firmware-Version"); fw != "" && fw != device.FirmwareVersion {
+			updates["firmware_version"] = fw
+		}
+		ctrl.DB.Model(&device).Updates(updates)
+
+		c.Set("device_id", device.ID)
+		c.Set("device_body", body)
+		c.Next()
+	}
+}
+
+// SeedDevices registers (or re-keys) the cameras listed in DEVICE_KEYS
+func (ctrl *Controller) SeedDevices(seeds []config.DeviceSeed) error {
+	for _, seed := range seeds {
+		device := models.Device{
+			ID:   seed.ID,
+			Name: "camera-" + seed.ID.String()[:8],
+			Key:  seed.Key,
+		}
+		if err := ctrl.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"key", "updated_at"}),
+		}).Create(&device).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleIngestFrame receives a JPEG frame from a camera, stores it in the
+// source bucket, records it and makes it available to the generators at once.
+// The optional X-Capture-Time header (unix milliseconds) dates the frame.
+func (ctrl *Controller) HandleIngestFrame(c *gin.Context) {
+	deviceIDInterface, _ := c.Get("device_id")
+	deviceID := deviceIDInterface.(uuid.UUID)
+	bodyInterface, _ := c.Get("device_body")
+	body := bodyInterface.([]byte)
+
+	if !bytes.HasPrefix(body, jpegMagic) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Frame must be a JPEG image"})
+		return
+	}
+
+	now := time.Now()
+	capturedAt := now
+	if raw := c.GetHeader("X-Capture-Time"); raw != "" {
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid X-Capture-Time"})
+			return
+		}
+		capturedAt = time.UnixMilli(ms)
+		if capturedAt.After(now.Add(deviceMaxSkew)) || capturedAt.Before(now.Add(-maxFrameDelay)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "X-Capture-Time is out of range"})
+			return
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	digest := hex.EncodeToString(sum[:])
+
+	frame := models.Frame{
+		DeviceID:   deviceID,
+		CapturedAt: capturedAt,
+		Size:       int64(len(body)),
+		SHA256:     digest,
+		Status:     models.FrameAccepted,
+	}
+
+	// A repeated hash is a replayed request, a stuck camera or a frame
+	// copied from another device
+	var seen models.Frame
+	if ctrl.DB.Where("sha256 = ?", digest).Limit(1).Find(&seen).RowsAffected > 0 {
+		if seen.DeviceID == deviceID {
+			c.JSON(http.StatusConflict, gin.H{"error": "Duplicate frame"})
+			return
+		}
+		frame.Status, frame.RejectReason = models.FrameRejected, models.RejectDuplicate
+	}
+	// Keep the lava_ prefix so S3 listing (GetLatestLavaLampImage) still finds it
+	key := fmt.Sprintf("lava_%s_%d.jpg", deviceID, capturedAt.UnixMilli())
+	if frame.Status == models.FrameAccepted {
+		ctrl.assessFrame(&frame, body, key)
+	}
+
+	if frame.Status == models.FrameRejected {
+		if err := ctrl.DB.Create(&frame).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record frame"})
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "Frame rejected",
+			"reason": frame.RejectReason,
+			"frame":  frame,
+		})
+		return
+	}
+
+	if _, err := ctrl.SourceS3.UploadImage(key, body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store frame"})
+		return
+	}
+	frame.S3Key = key
+	if err := ctrl.DB.Create(&frame).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record frame"})
+		return
+	}
+
+	if ctrl.Mixer != nil {
+		ctrl.Mixer.AddFrame(deviceID, body)
+	}
+	ctrl.Entropy.AddFrame(&services.PooledFrame{
+		DeviceID:   deviceID,
+		Key:        key,
+		Data:       body,
+		CapturedAt: capturedAt,
+	})
+
+	c.JSON(http.StatusCreated, frame)
+}
+
+// assessFrame runs the quality and liveness checks on a frame, comparing it
+// with the device's recent accepted frames, then the entropy health tests.
+// It marks the frame rejected on failure.
+func (ctrl *Controller) assessFrame(frame *models.Frame, data []byte, key string) {
+	stats, err := services.AnalyzeFrame(data)
+	if err != nil {
+		frame.Status, frame.RejectReason = models.FrameRejected, models.RejectUndecodable
+		return
+	}
+	frame.Brightness = stats.Brightness
+	frame.Contrast = stats.Contrast
+	frame.DHash = int64(stats.DHash)
+	frame.Thumb = stats.Thumb
+	if ctrl.FrameQuality == nil {
+		return
+	}
+
+	var previous []models.Frame
+	ctrl.DB.Select("thumb", "d_hash").
+		Where("device_id = ? AND status = ?", frame.DeviceID, models.FrameAccepted).
+		Order("captured_at desc").Limit(ctrl.FrameQuality.RecentFrames).Find(&previous)
+	recent := make([]*services.FrameStats, 0, len(previous))
+	for _, p := range previous {
+		recent = append(recent, &services.FrameStats{Thumb: p.Thumb, DHash: uint64(p.DHash)})
+	}
+	if len(recent) > 0 {
+		diff := services.ThumbDifference(stats.Thumb, recent[0].Thumb)
+		frame.Difference = &diff
+	}
+
+	if reason := services.CheckFrame(ctrl.FrameQuality, stats, recent); reason != "" {
+		frame.Status, frame.RejectReason = models.FrameRejected, reason
+		return
+	}
+	if ctrl.Health != nil && ctrl.Health.Test(key, stats.Noise) != nil {
+		frame.Status, frame.RejectReason = models.FrameRejected, models.RejectHealthTest
+	}
+}
+
+// fleetDigest hashes the frames every camera pushed recently, or is nil
+func (ctrl *Controller) fleetDigest() []byte {
+	if ctrl.Entropy == nil {
+		return nil
+	}
+	return ctrl.Entropy.FleetDigest(poolFrameMaxAge)
+}
+
+// checkStoredFrame runs the single-frame quality checks and the health
+// tests on a frame this instance didn't ingest itself
+func (ctrl *Controller) checkStoredFrame(key string, data []byte) error {
+	if ctrl.FrameQuality == nil && ctrl.Health == nil {
+		return nil
+	}
+	stats, err := services.AnalyzeFrame(data)
+	if err != nil {
+		return fmt.Errorf("frame %s rejected: %s", key, models.RejectUndecodable)
+	}
+	if ctrl.FrameQuality != nil {
+		if reason := services.CheckFrame(ctrl.FrameQuality, stats, nil); reason != "" {
+			return fmt.Errorf("frame %s rejected: %s", key, reason)
+		}
+	}
+	if ctrl.Health != nil {
+		if err := ctrl.Health.Test(key, stats.Noise); err != nil {
+			return fmt.Errorf("frame %s rejected: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// latestFrame returns the newest lava lamp frame that passed the quality
+// checks: from this instance's entropy pool, else the newest accepted frame
+// any instance recorded, else the newest object in the source bucket.
+// It returns services.ErrEntropyUnhealthy while the health tests are in
+// alarm or haven't passed yet.
+func (ctrl *Controller) latestFrame() (string, []byte, error) {
+	key, data, err := ctrl.findLatestFrame()
+	if err != nil {
+		return "", nil, err
+	}
+	if ctrl.Health != nil {
+		if err := ctrl.Health.Ready(); err != nil {
+			return "", nil, err
+		}
+	}
+	return key, data, nil
+}
+
+func (ctrl *Controller) findLatestFrame() (string, []byte, error) {
+	if ctrl.Entropy != nil {
+		// Already checked when it was ingested
+		if frame, ok := ctrl.Entropy.Latest(poolFrameMaxAge); ok {
+			return frame.Key, frame.Data, nil
+		}
+	}
+
+	if ctrl.DB != nil {
+		var frame models.Frame
+		if ctrl.DB.Where("status = ? AND captured_at > ?", models.FrameAccepted, time.Now().Add(-poolFrameMaxAge)).
+			Order("captured_at desc").Limit(1).Find(&frame).RowsAffected > 0 {
+			data, err := ctrl.SourceS3.DownloadImage(frame.S3Key)
+			if err == nil {
+				return frame.S3Key, data, ctrl.mixStoredFrame(frame.DeviceID, frame.S3Key, data)
+			}
+		}
+	}
+
+	// Objects uploaded straight to S3 by older firmware were never checked.
+	// There's no history to compare against, so only single-frame checks apply.
+	key, err := ctrl.SourceS3.GetLatestLavaLampImage()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find image: %w", err)
+	}
+	data, err := ctrl.SourceS3.DownloadImage(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	return key, data, ctrl.mixStoredFrame(uuid.Nil, key, data)
+}
+
+// mixStoredFrame checks a frame this instance didn't ingest and, if it
+// passes, credits it to the mixer. The mixer ignores a frame it has already
+// seen from the same camera.
+func (ctrl *Controller) mixStoredFrame(deviceID uuid.UUID, key string, data []byte) error {
+	if err := ctrl.checkStoredFrame(key, data); err != nil {
+		return err
+	}
+	if ctrl.Mixer != nil {
+		ctrl.Mixer.AddFrame(deviceID, data)
+	}
+	return nil
+}
+
+// mixedEntropy draws n bytes from the mixer, or from the OS when no mixer
+// is configured
+func (ctrl *Controller) mixedEntropy(n int) ([]byte, error) {
+	if ctrl.Mixer != nil {
+		return ctrl.Mixer.Extract(n)
+	}
+	out := make([]byte, n)
+	if _, err := rand.Read(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// respondFrameError reports a latestFrame failure, telling clients plainly
+// when generation is suspended by the health tests
+func (ctrl *Controller) respondFrameError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrEntropyUnhealthy) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":  "Generation is suspended: the entropy source has not passed its health tests",
+			"health": ctrl.Health.Status(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInsufficientEntropy) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Generation is suspended: not enough entropy has been collected yet",
+			"mixer": ctrl.Mixer.Status(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image: " + err.Error()})
+}
+
+// HandleAdminEntropyHealth reports the health test state and cutoffs
+func (ctrl *Controller) HandleAdminEntropyHealth(c *gin.Context) {
+	if ctrl.Health == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Health tests are not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.Health.Status())
+}
+
+// HandleAdminEntropySources reports each entropy source's credited
+// contribution to the mixer
+func (ctrl *Controller) HandleAdminEntropySources(c *gin.Context) {
+	if ctrl.Mixer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entropy mixer is not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.Mixer.Status())
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const eventKeepAlive = 25 * time.Second
+
+// maxEventIDs keeps each vault.changed event well under Postgres's 8000 byte
+// NOTIFY payload limit (a quoted UUID is 39 bytes in JSON)
+const maxEventIDs = 100
+
+// notifyVaultChanged tells the user's other devices to pull /api/sync. Large
+// batches (bulk edits, sync pushes) are split across several events.
+func (ctrl *Controller) notifyVaultChanged(userID uuid.UUID, action string, ids ...uuid.UUID) {
+	if ctrl.Events == nil {
+		return
+	}
+	for start := 0; start == 0 || start < len(ids); start += maxEventIDs {
+		ctrl.Events.Publish(services.EventVaultChanged, &userID, gin.H{
+			"action": action,
+			"ids":    ids[start:min(start+maxEventIDs, len(ids))],
+			"total":  len(ids),
+		})
+	}
+}
+
+// HandleEventStream streams events to the client using Server-Sent Events
+func (ctrl *Controller) HandleEventStream(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	sub := ctrl.Events.Subscribe(userID)
+	defer ctrl.Events.Unsubscribe(sub)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case evt := <-sub.C:
+			c.SSEvent(evt.Type, evt)
+			return true
+		case <-keepAlive.C:
+			// SSE comment line keeps idle connections open through proxies
+			io.WriteString(w, ": keep-alive\n\n")
+			return true
+		}
+	})
+}
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkWebSocketOrigin,
+}
+
+// checkWebSocketOrigin blocks cross-site pages from riding the session cookie.
+// Native clients send no Origin; browsers must match the host or WS_ALLOWED_ORIGINS.
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" && strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleEventSocket streams events to the client over a WebSocket
+func (ctrl *Controller) HandleEventSocket(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written the HTTP error
+		return
+	}
+	defer conn.Close()
+
+	sub := ctrl.Events.Subscribe(userID)
+	defer ctrl.Events.Unsubscribe(sub)
+
+	// Reader: we don't accept client messages, but must read to process
+	// pongs and notice when the client goes away.
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * eventKeepAlive))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * eventKeepAlive))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(eventKeepAlive)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case evt := <-sub.C:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(evt); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
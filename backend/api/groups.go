@@ -1,13 +1,46 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrGroupNotFound is returned when a group_id does not belong to the caller
+var ErrGroupNotFound = errors.New("group not found")
+
+// validateGroupID checks that groupID (if set) is a group owned by userID.
+// A nil groupID is valid and means "no group".
+func validateGroupID(db *gorm.DB, userID uuid.UUID, groupID *uuid.UUID) error {
+	if groupID == nil {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&models.VaultGroup{}).Where("id = ? AND user_id = ?", *groupID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// respondGroupError writes the response for a failed validateGroupID call
+func respondGroupError(c *gin.Context, err error) {
+	if errors.Is(err, ErrGroupNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify group"})
+}
+
 // HandleListGroups returns the user's groups
 func (ctrl *Controller) HandleListGroups(c *gin.Context) {
 	userIDInterface, exists := c.Get("user_id")
@@ -60,6 +93,8 @@ func (ctrl *Controller) HandleCreateGroup(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
 		return
 	}
+	ctrl.notifyVaultChanged(userID, "group_created", newGroup.ID)
+	ctrl.audit(c, AuditGroupCreate, models.AuditSuccess, "group", newGroup.ID.String(), "")
 
 	c.JSON(http.StatusOK, newGroup)
 }
@@ -90,6 +125,77 @@ func (ctrl *Controller) HandleDeleteGroup(c *gin.Context) {
 
 	// Unlink passwords in this group
 	ctrl.DB.Model(&models.PasswordEntry{}).Where("group_id = ?", id).Update("group_id", nil)
+	ctrl.notifyVaultChanged(userID, "group_deleted", id)
+	ctrl.audit(c, AuditGroupDelete, models.AuditSuccess, "group", id.String(), "")
 
 	c.JSON(http.StatusOK, gin.H{"message": "Group deleted"})
 }
+
+// HandleMovePasswords moves many entries into a group (or out of any group) in one transaction.
+// IDs that don't exist or aren't the user's are reported as skipped.
+func (ctrl *Controller) HandleMovePasswords(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	type MoveRequest struct {
+		IDs     []uuid.UUID `json:"ids" binding:"required"`
+		GroupID *uuid.UUID  `json:"group_id"` // null moves entries out of any group
+	}
+	var req MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids required"})
+		return
+	}
+
+	var moved []models.PasswordEntry
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateGroupID(tx, userID, req.GroupID); err != nil {
+			return err
+		}
+
+		return tx.Model(&moved).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Where("id IN ? AND user_id = ?", req.IDs, userID).
+			Update("group_id", req.GroupID).Error
+	})
+	if err != nil {
+		ctrl.audit(c, AuditPasswordMove, models.AuditFailure, "group", groupTarget(req.GroupID), err.Error())
+		respondGroupError(c, err)
+		return
+	}
+	movedIDs := make([]uuid.UUID, 0, len(moved))
+	isMoved := make(map[uuid.UUID]bool, len(moved))
+	for _, e := range moved {
+		movedIDs = append(movedIDs, e.ID)
+		isMoved[e.ID] = true
+	}
+	skipped := []uuid.UUID{}
+	for _, id := range req.IDs {
+		if !isMoved[id] {
+			isMoved[id] = true // report duplicates once
+			skipped = append(skipped, id)
+		}
+	}
+	if len(movedIDs) > 0 {
+		ctrl.notifyVaultChanged(userID, "moved", movedIDs...)
+	}
+	ctrl.audit(c, AuditPasswordMove, models.AuditSuccess, "group", groupTarget(req.GroupID), fmt.Sprintf("%d entries", len(movedIDs)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"moved":    len(movedIDs),
+		"skipped":  skipped,
+		"group_id": req.GroupID,
+	})
+}
+
+// groupTarget is the audit target for a group, "" meaning no group
+func groupTarget(groupID *uuid.UUID) string {
+	if groupID == nil {
+		return ""
+	}
+	return groupID.String()
+}
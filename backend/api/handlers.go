@@ -1,15 +1,19 @@
 package api
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
 	"github.com/anthonyhana04/Delta-Hacks-2026/backend/database"
 	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
 	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -19,7 +23,26 @@ type Controller struct {
 	GeneratedS3   *services.S3Service // For AI output
 	AIService     *services.AIService
 	KeyGenService *services.KeyGenService
+	Events        *services.EventBus
+	Audit         *services.AuditLog
+	Entropy       *services.EntropyPool
+	Tokens        *services.TokenService
+	Identity      *services.OIDCRegistry
+	Passkeys      *webauthn.WebAuthn
+	PasskeyPolicy *config.WebAuthnConfig
+	RateLimits    *config.RateLimitConfig
+	Limiter       services.RateLimiter
+	Lockouts      services.LockoutStore
+	DeviceConfig  *config.DeviceConfig
+	FrameQuality  *config.FrameQualityConfig
+	Health        *services.EntropyHealth
+	Mixer         *services.EntropyMixer
+	Beacon        *services.Beacon
+	DRBG          *services.DRBG
 	DB            *gorm.DB
+	SessionTTL    time.Duration
+	ReauthWindow  time.Duration
+	DeletionGrace time.Duration
 }
 
 func NewController() *Controller {
@@ -53,6 +76,10 @@ func NewController() *Controller {
 		GeneratedS3:   genS3,
 		AIService:     aiSvc,
 		KeyGenService: services.NewKeyGenService(),
+		Events:        services.NewEventBus(db),
+		Audit:         services.NewAuditLog(db),
+		Entropy:       services.NewEntropyPool(),
+		DRBG:          services.NewDRBG(),
 		DB:            db,
 	}
 }
@@ -68,25 +95,48 @@ func (ctrl *Controller) HandleGeneratePassword(c *gin.Context) {
 	// Ignore error if body is empty or malformed, just default to 20
 	c.ShouldBindJSON(&req)
 
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	// Reject foreign groups up front so the client can't save into them later
+	if err := validateGroupID(ctrl.DB, userID, req.GroupID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	// Fall back to the user's synced generator defaults
+	prefs, err := loadPreferences(ctrl.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load preferences"})
+		return
+	}
+	if req.GroupID == nil && prefs.Data.DefaultGroupID != nil &&
+		validateGroupID(ctrl.DB, userID, prefs.Data.DefaultGroupID) == nil {
+		req.GroupID = prefs.Data.DefaultGroupID
+	}
+
 	passwordLength := req.Length
-	if passwordLength < 8 {
-		passwordLength = 20
+	if passwordLength == 0 {
+		passwordLength = prefs.Data.Generator.Length
+	}
+	if passwordLength < models.MinGeneratorLength {
+		passwordLength = models.DefaultGeneratorLength
 	}
-	if passwordLength > 32 {
-		passwordLength = 32
+	if passwordLength > models.MaxGeneratorLength {
+		passwordLength = models.MaxGeneratorLength
 	}
 
-	// 2. Find latest original image
-	key, err := ctrl.SourceS3.GetLatestLavaLampImage()
+	// 2-3. Latest lava lamp frame (pushed by a camera, or from the source bucket)
+	key, imgData, err := ctrl.latestFrame()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find image: " + err.Error()})
+		ctrl.respondFrameError(c, err)
 		return
 	}
-
-	// 3. Download it
-	imgData, err := ctrl.SourceS3.DownloadImage(key)
+	// Draw the nonce first: it takes the mixer's credit, so a refusal comes
+	// before we pay for the AI call and upload rather than after
+	nonce, err := ctrl.provenanceNonce()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download image: " + err.Error()})
+		ctrl.respondFrameError(c, err)
 		return
 	}
 
@@ -104,17 +154,41 @@ func (ctrl *Controller) HandleGeneratePassword(c *gin.Context) {
 	}
 
 	// 5. Upload AI Result to Second Bucket
-	wpKey := "wallpaper_" + fmt.Sprintf("%d", time.Now().Unix()) + ".jpg"
+	// The user ID in the key lets account deletion find wallpapers that were never saved
+	wpKey := wallpaperKeyPrefix(userID) + fmt.Sprintf("%d", time.Now().UnixNano()) + ".jpg"
 	_, err = ctrl.GeneratedS3.UploadImage(wpKey, wallpaperData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload wallpaper: " + err.Error()})
 		return
 	}
 
-	// 6. Generate Password (FROM AI DATA)
-	password := ctrl.KeyGenService.GeneratePassword(wallpaperData, passwordLength)
+	// 6. Generate Password (FROM AI DATA), keyed by the server nonce so the
+	// buckets alone aren't enough to reproduce it. The nonce comes from the
+	// mixer, so the wallpaper alone can't steer the result either.
+	if ctrl.Mixer != nil {
+		ctrl.Mixer.AddWallpaper(wallpaperData)
+	}
+	fleet := ctrl.fleetDigest()
+	password := ctrl.KeyGenService.GeneratePassword(services.PasswordSeed(nonce, wallpaperData, fleet), passwordLength)
 	entropy := ctrl.KeyGenService.CalculateEntropyEstimate(password)
 
+	provenance := models.PasswordProvenance{
+		UserID:           userID,
+		FrameS3Key:       key,
+		FrameSHA256:      services.SHA256Hex(imgData),
+		WallpaperS3Key:   wpKey,
+		WallpaperSHA256:  services.SHA256Hex(wallpaperData),
+		FleetDigest:      hex.EncodeToString(fleet),
+		Length:           passwordLength,
+		Nonce:            nonce,
+		NonceCommitment:  services.NonceCommitment(nonce),
+		OutputCommitment: services.OutputCommitment(nonce, password),
+	}
+	if err := ctrl.DB.Create(&provenance).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record provenance"})
+		return
+	}
+
 	// 7. DO NOT Save to DB automatically.
 	// We just return the keys and data. Use HandleCreatePassword to save.
 
@@ -122,6 +196,8 @@ func (ctrl *Controller) HandleGeneratePassword(c *gin.Context) {
 	imgUrl, _ := ctrl.SourceS3.GeneratePresignedGETURL(key)
 	wpUrl, _ := ctrl.GeneratedS3.GeneratePresignedGETURL(wpKey)
 
+	ctrl.audit(c, AuditPasswordGenerate, models.AuditSuccess, "wallpaper", wpKey, "")
+
 	c.JSON(http.StatusOK, gin.H{
 		"password":         password,
 		"entropy_bits":     entropy,
@@ -129,6 +205,9 @@ func (ctrl *Controller) HandleGeneratePassword(c *gin.Context) {
 		"wallpaper_url":    wpUrl,  // Preview URL
 		"s3_key":           key,    // To pass back on save
 		"wallpaper_s3_key": wpKey,  // To pass back on save
+		"group_id":         req.GroupID,
+		"provenance_id":    provenance.ID, // To pass back on save
+		"provenance":       provenance,
 		"created_at":       time.Now(),
 	})
 }
@@ -148,8 +227,11 @@ func (ctrl *Controller) HandleCreatePassword(c *gin.Context) {
 		Name           string     `json:"name"`
 		Username       string     `json:"username"`
 		WebsiteURL     string     `json:"website_url"`
+		Favorite       bool       `json:"favorite"`
+		Tags           []string   `json:"tags"`
 		S3Key          string     `json:"s3_key"`           // Optional
 		WallpaperS3Key string     `json:"wallpaper_s3_key"` // Optional
+		ProvenanceID   *uuid.UUID `json:"provenance_id"`    // Optional, from generate-password
 	}
 
 	var req CreateRequest
@@ -158,6 +240,15 @@ func (ctrl *Controller) HandleCreatePassword(c *gin.Context) {
 		return
 	}
 
+	if userIDPtr == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	if err := validateGroupID(ctrl.DB, *userIDPtr, req.GroupID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
 	// We check entropy for manual passwords too
 	entropy := ctrl.KeyGenService.CalculateEntropyEstimate(req.Password)
 
@@ -169,14 +260,44 @@ func (ctrl *Controller) HandleCreatePassword(c *gin.Context) {
 		Name:           req.Name,
 		Username:       req.Username,
 		WebsiteURL:     req.WebsiteURL,
+		Favorite:       req.Favorite,
+		Tags:           normalizeTags(req.Tags),
 		S3Key:          req.S3Key,          // Persist if provided
 		WallpaperS3Key: req.WallpaperS3Key, // Persist if provided
 	}
 
-	if err := ctrl.DB.Create(&entry).Error; err != nil {
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var provenance *models.PasswordProvenance
+		if req.ProvenanceID != nil {
+			p, err := unclaimedProvenance(tx, *userIDPtr, *req.ProvenanceID, entry.Password)
+			if err != nil {
+				return err
+			}
+			provenance = p
+			entry.S3Key, entry.WallpaperS3Key = p.FrameS3Key, p.WallpaperS3Key
+		} else if entry.WallpaperS3Key != "" {
+			if err := checkWallpaperKey(tx, *userIDPtr, entry.WallpaperS3Key); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		if provenance == nil {
+			return nil
+		}
+		return tx.Model(provenance).Update("entry_id", entry.ID).Error
+	})
+	if errors.Is(err, errProvenanceNotFound) || errors.Is(err, errProvenanceMismatch) || errors.Is(err, errUnknownWallpaper) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create password"})
 		return
 	}
+	ctrl.notifyVaultChanged(*userIDPtr, "created", entry.ID)
+	ctrl.audit(c, AuditPasswordCreate, models.AuditSuccess, "password", entry.ID.String(), "")
 
 	c.JSON(http.StatusOK, gin.H{
 		"id":          entry.ID,
@@ -185,10 +306,13 @@ func (ctrl *Controller) HandleCreatePassword(c *gin.Context) {
 		"name":        entry.Name,
 		"username":    entry.Username,
 		"website_url": entry.WebsiteURL,
+		"favorite":    entry.Favorite,
+		"tags":        entry.Tags,
 	})
 }
 
-// HandleListPasswords returns the history for the logged-in user
+// HandleListPasswords returns the history for the logged-in user.
+// Only metadata is listed; secrets come from HandleRevealPassword.
 func (ctrl *Controller) HandleListPasswords(c *gin.Context) {
 	userIDInterface, exists := c.Get("user_id")
 	if !exists {
@@ -206,7 +330,6 @@ func (ctrl *Controller) HandleListPasswords(c *gin.Context) {
 	// Presign URLs
 	type ResponseEntry struct {
 		ID           uuid.UUID  `json:"id"`
-		Password     string     `json:"password"`
 		Entropy      int64      `json:"entropy_bits"`
 		WallpaperURL string     `json:"wallpaper_url"`
 		Date         time.Time  `json:"created_at"`
@@ -214,6 +337,8 @@ func (ctrl *Controller) HandleListPasswords(c *gin.Context) {
 		Name         string     `json:"name"`
 		Username     string     `json:"username"`
 		WebsiteURL   string     `json:"website_url"`
+		Favorite     bool       `json:"favorite"`
+		Tags         []string   `json:"tags"`
 	}
 
 	var response []ResponseEntry
@@ -224,7 +349,6 @@ func (ctrl *Controller) HandleListPasswords(c *gin.Context) {
 		}
 		response = append(response, ResponseEntry{
 			ID:           e.ID,
-			Password:     e.Password,
 			Entropy:      int64(e.EntropyScore),
 			WallpaperURL: url,
 			Date:         e.CreatedAt,
@@ -232,6 +356,8 @@ func (ctrl *Controller) HandleListPasswords(c *gin.Context) {
 			Name:         e.Name,
 			Username:     e.Username,
 			WebsiteURL:   e.WebsiteURL,
+			Favorite:     e.Favorite,
+			Tags:         e.Tags,
 		})
 	}
 
@@ -250,10 +376,17 @@ func (ctrl *Controller) HandleDeletePassword(c *gin.Context) {
 	userIDInterface, _ := c.Get("user_id")
 	userID := userIDInterface.(uuid.UUID)
 
-	if err := ctrl.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PasswordEntry{}).Error; err != nil {
+	result := ctrl.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PasswordEntry{})
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete password"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		ctrl.audit(c, AuditPasswordDelete, models.AuditFailure, "password", id.String(), "not found")
+	} else {
+		ctrl.notifyVaultChanged(userID, "deleted", id)
+		ctrl.audit(c, AuditPasswordDelete, models.AuditSuccess, "password", id.String(), "")
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
 }
@@ -265,17 +398,17 @@ func (ctrl *Controller) StartMFAGeneratorLoop() {
 		for range ticker.C {
 			fmt.Println("Generating new MFA seed...")
 
-			// 1. Get latest image from Source S3
-			key, err := ctrl.SourceS3.GetLatestLavaLampImage()
+			// 1-2. Get the latest lava lamp frame
+			key, imgData, err := ctrl.latestFrame()
 			if err != nil {
 				fmt.Printf("MFA Loop Error: Failed to get latest image: %v\n", err)
 				continue
 			}
-
-			// 2. Download Image
-			imgData, err := ctrl.SourceS3.DownloadImage(key)
+			// Take the mixer's output before the AI call and upload, so a
+			// refusal doesn't cost either
+			mixed, err := ctrl.mixedEntropy(32)
 			if err != nil {
-				fmt.Printf("MFA Loop Error: Failed to download image: %v\n", err)
+				fmt.Printf("MFA Loop: waiting for the entropy mixer: %v\n", err)
 				continue
 			}
 
@@ -305,8 +438,11 @@ func (ctrl *Controller) StartMFAGeneratorLoop() {
 				key = wpKey
 			}
 
-			// 5. Generate Seed
-			seed := ctrl.KeyGenService.GenerateMFACode(wallpaperData)
+			// 5. Generate Seed, keyed by the mixer so the wallpaper can't choose it
+			if ctrl.Mixer != nil {
+				ctrl.Mixer.AddWallpaper(wallpaperData)
+			}
+			seed := ctrl.KeyGenService.GenerateMFACode(services.KeyedSeed("lavalock-mfa", mixed, wallpaperData, ctrl.fleetDigest()))
 
 			// 6. Store in DB
 			code := models.MFACode{
@@ -322,6 +458,10 @@ func (ctrl *Controller) StartMFAGeneratorLoop() {
 			}
 
 			fmt.Printf("MFA Seed Generated: %s\n", seed)
+			ctrl.Events.Publish(services.EventMFACode, nil, gin.H{
+				"seed":        code.Seed,
+				"valid_until": code.ExpiresAt,
+			})
 
 			// 7. Strict Cleanup: Delete ALL old codes immediately
 			// The user wants old codes gone as soon as a new one appears.
@@ -340,6 +480,7 @@ func (ctrl *Controller) HandleGenerateMFACode(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No valid MFA code found (generating...)"})
 		return
 	}
+	ctrl.audit(c, AuditMFAView, models.AuditSuccess, "mfa_code", code.ID.String(), "")
 
 	c.JSON(http.StatusOK, gin.H{
 		"seed":        code.Seed,
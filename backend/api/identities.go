@@ -0,0 +1,238 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// verifyClaims validates an ID token against the named provider.
+// On failure it writes the error response and returns false.
+func (ctrl *Controller) verifyClaims(c *gin.Context, provider, idToken string) (*services.IDTokenClaims, bool) {
+	if ctrl.Identity == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server misconfiguration: no identity providers"})
+		return nil, false
+	}
+	p, err := ctrl.Identity.Get(provider)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return nil, false
+	}
+
+	claims, err := p.Verify(c.Request.Context(), idToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token: " + err.Error()})
+		return nil, false
+	}
+	return claims, true
+}
+
+// verifyIdentity validates an ID token and finds or creates the linked user.
+// On failure it writes the error response and returns false.
+func (ctrl *Controller) verifyIdentity(c *gin.Context, provider, idToken string) (*models.User, bool) {
+	claims, ok := ctrl.verifyClaims(c, provider, idToken)
+	if !ok {
+		return nil, false
+	}
+
+	var user models.User
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+		if err == nil {
+			if err := tx.First(&user, "id = ?", identity.UserID).Error; err != nil {
+				return err
+			}
+			if claims.Email != "" && identity.Email != claims.Email {
+				tx.Model(&identity).Update("email", claims.Email)
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		} else {
+			// Users created before identities existed only have GoogleID
+			legacy := provider == "google" &&
+				tx.Where(&models.User{GoogleID: claims.Subject}).
+					Where("NOT EXISTS (SELECT 1 FROM user_identities WHERE user_identities.user_id = users.id)").
+					First(&user).Error == nil
+			if !legacy {
+				user = models.User{
+					ID:    uuid.New(),
+					Email: claims.Email,
+					Name:  claims.Name,
+				}
+				if provider == "google" {
+					user.GoogleID = claims.Subject
+				}
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&models.UserIdentity{
+				UserID:   user.ID,
+				Provider: provider,
+				Subject:  claims.Subject,
+				Email:    claims.Email,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Update user info
+		if claims.Email != "" {
+			user.Email = claims.Email
+		}
+		if claims.Name != "" {
+			user.Name = claims.Name
+		}
+		return tx.Save(&user).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// HandleListProviders returns the configured identity provider names
+func (ctrl *Controller) HandleListProviders(c *gin.Context) {
+	names := []string{}
+	if ctrl.Identity != nil {
+		names = ctrl.Identity.Names()
+	}
+	sort.Strings(names)
+	c.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+// HandleListIdentities returns the identities linked to the current user
+func (ctrl *Controller) HandleListIdentities(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	var identities []models.UserIdentity
+	if err := ctrl.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&identities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch identities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// HandleLinkIdentity links another provider account to the current user.
+// The caller proves ownership by presenting a fresh ID token from that provider.
+func (ctrl *Controller) HandleLinkIdentity(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+	provider := c.Param("provider")
+
+	type LinkRequest struct {
+		IDToken string `json:"id_token" binding:"required"`
+	}
+	var req LinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id_token required"})
+		return
+	}
+
+	claims, ok := ctrl.verifyClaims(c, provider, req.IDToken)
+	if !ok {
+		return
+	}
+
+	var existing models.UserIdentity
+	err := ctrl.DB.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID == userID {
+			c.JSON(http.StatusOK, existing)
+			return
+		}
+		ctrl.audit(c, AuditIdentityLink, models.AuditFailure, "identity", existing.ID.String(), "linked to another account")
+		c.JSON(http.StatusConflict, gin.H{"error": "Identity is already linked to another account"})
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link identity"})
+		return
+	}
+
+	identity := models.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := ctrl.DB.Create(&identity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link identity"})
+		return
+	}
+	ctrl.audit(c, AuditIdentityLink, models.AuditSuccess, "identity", identity.ID.String(), provider)
+
+	c.JSON(http.StatusOK, identity)
+}
+
+// HandleUnlinkIdentity removes a linked identity. The last identity can't be
+// removed, otherwise the user would have no way to sign in.
+func (ctrl *Controller) HandleUnlinkIdentity(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Identity ID format"})
+		return
+	}
+
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var status int
+	var message string
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			status, message = http.StatusConflict, "Cannot unlink the only sign-in method"
+			return nil
+		}
+
+		var identity models.UserIdentity
+		if tx.Where("id = ? AND user_id = ?", id, userID).Limit(1).Find(&identity).RowsAffected == 0 {
+			status, message = http.StatusNotFound, "Identity not found"
+			return nil
+		}
+		if err := tx.Delete(&identity).Error; err != nil {
+			return err
+		}
+		// Otherwise the legacy GoogleID fallback would re-link it on the next sign-in
+		if identity.Provider == "google" {
+			return tx.Model(&models.User{}).Where("id = ? AND google_id = ?", userID, identity.Subject).
+				Update("google_id", "").Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		return
+	}
+	if status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	ctrl.audit(c, AuditIdentityUnlink, models.AuditSuccess, "identity", id.String(), "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked"})
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxNameLen = 100
+
+var (
+	errPreferencesConflict = errors.New("preferences changed since version")
+	errInvalidProfile      = errors.New("invalid profile")
+)
+
+// loadPreferences returns the user's stored preferences, or the defaults
+func loadPreferences(db *gorm.DB, userID uuid.UUID) (*models.UserPreferences, error) {
+	var prefs models.UserPreferences
+	result := db.Where("user_id = ?", userID).Limit(1).Find(&prefs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		prefs = models.UserPreferences{UserID: userID, Data: models.DefaultPreferences()}
+	}
+	return &prefs, nil
+}
+
+// mergePatch applies an RFC 7396 JSON merge patch: objects merge recursively,
+// null removes a key and anything else replaces the target value
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
+
+// applyPreferencesPatch merges patch into current and validates the result
+func applyPreferencesPatch(tx *gorm.DB, userID uuid.UUID, current models.Preferences, patch json.RawMessage) (models.Preferences, error) {
+	var doc, patchDoc interface{}
+	raw, _ := json.Marshal(current)
+	json.Unmarshal(raw, &doc)
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return current, fmt.Errorf("%w: %v", errInvalidProfile, err)
+	}
+	merged, _ := json.Marshal(mergePatch(doc, patchDoc))
+
+	// Removed keys fall back to their defaults
+	next := models.DefaultPreferences()
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return current, fmt.Errorf("%w: %v", errInvalidProfile, err)
+	}
+
+	if l := next.Generator.Length; l < models.MinGeneratorLength || l > models.MaxGeneratorLength {
+		return current, fmt.Errorf("%w: generator.length must be between %d and %d",
+			errInvalidProfile, models.MinGeneratorLength, models.MaxGeneratorLength)
+	}
+	if err := validateGroupID(tx, userID, next.DefaultGroupID); err != nil {
+		if errors.Is(err, ErrGroupNotFound) {
+			return current, fmt.Errorf("%w: default_group_id not found", errInvalidProfile)
+		}
+		return current, err
+	}
+	return next, nil
+}
+
+func meResponse(user *models.User, prefs *models.UserPreferences) gin.H {
+	return gin.H{
+		"user":                   user,
+		"preferences":            prefs.Data,
+		"preferences_version":    prefs.Version,
+		"preferences_updated_at": prefs.UpdatedAt,
+	}
+}
+
+// HandleGetMe returns the current user and their synced preferences
+func (ctrl *Controller) HandleGetMe(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var user models.User
+	if err := ctrl.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+	prefs, err := loadPreferences(ctrl.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, meResponse(&user, prefs))
+}
+
+// HandlePatchMe updates the display name and/or preferences. Preferences are
+// a JSON merge patch, so clients only send the settings they changed. If
+// "version" is given and another device saved in between, nothing is
+// applied and 409 returns the current document.
+func (ctrl *Controller) HandlePatchMe(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	type PatchMeRequest struct {
+		Name        *string         `json:"name"`
+		Preferences json.RawMessage `json:"preferences"`
+		Version     *int64          `json:"version"`
+	}
+	var req PatchMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var (
+		user    models.User
+		prefs   *models.UserPreferences
+		changed bool
+	)
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		// Create the row if needed, then lock it so concurrent patches serialise
+		row := models.UserPreferences{UserID: userID, Data: models.DefaultPreferences()}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&row, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		prefs = &row
+
+		hasPatch := len(req.Preferences) > 0 && string(req.Preferences) != "null"
+		if hasPatch && req.Version != nil && *req.Version != row.Version {
+			return errPreferencesConflict
+		}
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" || len(name) > maxNameLen {
+				return fmt.Errorf("%w: name must be 1-%d characters", errInvalidProfile, maxNameLen)
+			}
+			if err := tx.Model(&user).Update("name", name).Error; err != nil {
+				return err
+			}
+			user.Name = name
+		}
+
+		if !hasPatch {
+			return nil
+		}
+		next, err := applyPreferencesPatch(tx, userID, row.Data, req.Preferences)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&row).Updates(map[string]interface{}{
+			"data":    next,
+			"version": row.Version + 1,
+		}).Error; err != nil {
+			return err
+		}
+		row.Data, row.Version = next, row.Version+1
+		changed = true
+		return nil
+	})
+	switch {
+	case errors.Is(err, errPreferencesConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Preferences were changed on another device",
+			"current": meResponse(&user, prefs),
+		})
+		return
+	case errors.Is(err, errInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	if changed && ctrl.Events != nil {
+		ctrl.Events.Publish(services.EventPreferencesChanged, &userID, gin.H{"version": prefs.Version})
+	}
+	c.JSON(http.StatusOK, meResponse(&user, prefs))
+}
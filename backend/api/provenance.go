@@ -0,0 +1,165 @@
+package api
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// provenanceClaimTTL is how long a generated password's provenance record
+// waits to be claimed by a save before it is swept
+const provenanceClaimTTL = 24 * time.Hour
+
+var (
+	errProvenanceNotFound = errors.New("Provenance record not found or already used")
+	errProvenanceMismatch = errors.New("Password does not match its provenance record")
+)
+
+// Outcomes of a single provenance check
+const (
+	provenancePass        = "pass"
+	provenanceFail        = "fail"
+	provenanceUnavailable = "unavailable" // e.g. the object is gone from the bucket
+)
+
+// unclaimedProvenance locks the user's provenance record for a password that
+// is about to be saved, checking the password is the one it was issued for
+func unclaimedProvenance(tx *gorm.DB, userID, id uuid.UUID, password string) (*models.PasswordProvenance, error) {
+	var p models.PasswordProvenance
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ? AND entry_id IS NULL AND created_at > ?", id, userID, time.Now().Add(-provenanceClaimTTL)).
+		Limit(1).Find(&p)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, errProvenanceNotFound
+	}
+	expected, _ := hex.DecodeString(p.OutputCommitment)
+	actual, _ := hex.DecodeString(services.OutputCommitment(p.Nonce, password))
+	if !hmac.Equal(expected, actual) {
+		return nil, errProvenanceMismatch
+	}
+	return &p, nil
+}
+
+// provenanceNonce draws a generation's nonce from the mixer, so the nonce
+// (and with it the password) depends on every source that fed it
+func (ctrl *Controller) provenanceNonce() ([]byte, error) {
+	if ctrl.Mixer == nil {
+		return services.NewProvenanceNonce()
+	}
+	return ctrl.Mixer.Extract(services.ProvenanceNonceBytes)
+}
+
+// sweepProvenance deletes expired records for passwords that were generated
+// but never saved, along with their nonces
+func (ctrl *Controller) sweepProvenance() {
+	res := ctrl.DB.Where("entry_id IS NULL AND created_at <= ?", time.Now().Add(-provenanceClaimTTL)).
+		Delete(&models.PasswordProvenance{})
+	if res.Error != nil {
+		log.Printf("Provenance sweep: %v", res.Error)
+	} else if res.RowsAffected > 0 {
+		log.Printf("Provenance sweep: removed %d unclaimed records", res.RowsAffected)
+	}
+}
+
+// checkObjectHash downloads an object and compares its hash to the record
+func checkObjectHash(s3 *services.S3Service, key, sha string) (string, []byte) {
+	if s3 == nil || key == "" {
+		return provenanceUnavailable, nil
+	}
+	data, err := s3.DownloadImage(key)
+	if err != nil {
+		return provenanceUnavailable, nil
+	}
+	if services.SHA256Hex(data) != sha {
+		return provenanceFail, nil
+	}
+	return provenancePass, data
+}
+
+// HandleVerifyProvenance re-checks a saved password against the record of
+// how it was generated: the frame and wallpaper still hash to the recorded
+// values, the nonce matches its commitment, and the password re-derives from
+// them. Only the outcomes are returned, never the nonce.
+func (ctrl *Controller) HandleVerifyProvenance(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var entry models.PasswordEntry
+	if err := ctrl.DB.Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Password not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch password"})
+		return
+	}
+	var p models.PasswordProvenance
+	res := ctrl.DB.Where("entry_id = ? AND user_id = ?", entry.ID, userID).Limit(1).Find(&p)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch provenance"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This password has no provenance record"})
+		return
+	}
+
+	checks := gin.H{}
+	frame, _ := checkObjectHash(ctrl.SourceS3, p.FrameS3Key, p.FrameSHA256)
+	wallpaper, wallpaperData := checkObjectHash(ctrl.GeneratedS3, p.WallpaperS3Key, p.WallpaperSHA256)
+	checks["frame"], checks["wallpaper"] = frame, wallpaper
+
+	checks["nonce_commitment"] = provenanceFail
+	if services.NonceCommitment(p.Nonce) == p.NonceCommitment {
+		checks["nonce_commitment"] = provenancePass
+	}
+	// Fails if the password was edited after it was saved
+	checks["password"] = provenanceFail
+	if services.OutputCommitment(p.Nonce, entry.Password) == p.OutputCommitment {
+		checks["password"] = provenancePass
+	}
+	checks["derivation"] = provenanceUnavailable
+	if wallpaperData != nil {
+		checks["derivation"] = provenanceFail
+		fleet, _ := hex.DecodeString(p.FleetDigest)
+		if ctrl.KeyGenService.GeneratePassword(services.PasswordSeed(p.Nonce, wallpaperData, fleet), p.Length) == entry.Password {
+			checks["derivation"] = provenancePass
+		}
+	}
+
+	verified := true
+	for _, outcome := range checks {
+		if outcome != provenancePass {
+			verified = false
+		}
+	}
+	result := models.AuditSuccess
+	if !verified {
+		result = models.AuditFailure
+	}
+	ctrl.audit(c, AuditPasswordVerify, result, "password", entry.ID.String(), "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"provenance": p,
+		"checks":     checks,
+		"verified":   verified,
+	})
+}
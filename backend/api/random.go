@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxRandomBytes = 4096
+	maxRandomCount = 1000
+	maxRandomUUIDs = 100
+	maxRandomItems = 1000
+)
+
+// randomQuota is the policy that meters DRBG output per caller, in bytes
+const randomQuota = "random_bytes"
+
+// drawRandom returns the DRBG to draw from, reseeding it from the mixer
+// first if a new frame has arrived, and charges cost bytes against the
+// caller's quota. The quota is taken before any reseed, so a caller who is
+// out of quota can't use up the mixer's credit. It refuses while the entropy
+// health tests are failing.
+func (ctrl *Controller) drawRandom(c *gin.Context, cost int) (io.Reader, bool) {
+	key, _, err := ctrl.latestFrame()
+	if err != nil {
+		ctrl.respondFrameError(c, err)
+		return nil, false
+	}
+	reseed := ctrl.DRBG.NeedsReseed(key)
+	// Don't charge for a draw that can't be served at all
+	if reseed && !ctrl.DRBG.Usable() && ctrl.Mixer != nil && !ctrl.Mixer.Ready() {
+		ctrl.respondFrameError(c, services.ErrInsufficientEntropy)
+		return nil, false
+	}
+	if !ctrl.takeRateLimit(c, randomQuota, cost) {
+		return nil, false
+	}
+	if reseed {
+		seed, err := ctrl.mixedEntropy(64)
+		switch {
+		case errors.Is(err, services.ErrInsufficientEntropy) && ctrl.DRBG.Usable():
+			// Each reseed uses up the mixer's credit; keep serving from the
+			// current seed until enough has been collected for the next one
+		case err != nil:
+			ctrl.respondFrameError(c, err)
+			return nil, false
+		default:
+			if err := ctrl.DRBG.Seed(key, seed); err != nil {
+				log.Printf("Random: failed to reseed: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seed random generator"})
+				return nil, false
+			}
+		}
+	}
+	return ctrl.DRBG, true
+}
+
+// queryInt parses an optional integer query parameter within [lo, hi]
+func queryInt(c *gin.Context, name string, def, lo, hi int64) (int64, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < lo || n > hi {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return n, true
+}
+
+// randomUint64 reads 8 bytes from r
+func randomUint64(r io.Reader) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// randomBelow returns a uniform value in [0, n), rejecting draws from the
+// partial block at the top of the range so there's no modulo bias. n == 0
+// means the full 64-bit range.
+func randomBelow(r io.Reader, n uint64) (uint64, error) {
+	for {
+		v, err := randomUint64(r)
+		if err != nil || n == 0 {
+			return v, err
+		}
+		if v < math.MaxUint64-math.MaxUint64%n {
+			return v % n, nil
+		}
+	}
+}
+
+// randomFloat returns a uniform value in [0, 1) with 53 bits of precision
+func randomFloat(r io.Reader) (float64, error) {
+	v, err := randomUint64(r)
+	return float64(v>>11) / (1 << 53), err
+}
+
+func respondRandomError(c *gin.Context, err error) {
+	log.Printf("Random: draw failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to draw random values"})
+}
+
+// HandleRandomBytes returns n random bytes as hex (default), base64 or raw
+func (ctrl *Controller) HandleRandomBytes(c *gin.Context) {
+	n, ok := queryInt(c, "n", 32, 1, maxRandomBytes)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "hex")
+	if format != "hex" && format != "base64" && format != "raw" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be hex, base64 or raw"})
+		return
+	}
+
+	r, ok := ctrl.drawRandom(c, int(n))
+	if !ok {
+		return
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		respondRandomError(c, err)
+		return
+	}
+
+	switch format {
+	case "raw":
+		c.Data(http.StatusOK, "application/octet-stream", buf)
+	case "base64":
+		c.JSON(http.StatusOK, gin.H{"bytes": n, "format": format, "data": base64.StdEncoding.EncodeToString(buf)})
+	default:
+		c.JSON(http.StatusOK, gin.H{"bytes": n, "format": format, "data": hex.EncodeToString(buf)})
+	}
+}
+
+// HandleRandomInt returns count uniform integers in [min, max] (inclusive)
+func (ctrl *Controller) HandleRandomInt(c *gin.Context) {
+	lo, ok := queryInt(c, "min", 0, math.MinInt64, math.MaxInt64)
+	if !ok {
+		return
+	}
+	hi, ok := queryInt(c, "max", 100, math.MinInt64, math.MaxInt64)
+	if !ok {
+		return
+	}
+	if hi < lo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max must be at least min"})
+		return
+	}
+	count, ok := queryInt(c, "count", 1, 1, maxRandomCount)
+	if !ok {
+		return
+	}
+
+	r, ok := ctrl.drawRandom(c, int(count)*8)
+	if !ok {
+		return
+	}
+	// Wraps to 0 for the full int64 range, which randomBelow treats as 2^64
+	span := uint64(hi-lo) + 1
+	values := make([]int64, count)
+	for i := range values {
+		v, err := randomBelow(r, span)
+		if err != nil {
+			respondRandomError(c, err)
+			return
+		}
+		values[i] = lo + int64(v)
+	}
+	c.JSON(http.StatusOK, gin.H{"min": lo, "max": hi, "values": values})
+}
+
+// HandleRandomUUID returns count version 4 UUIDs
+func (ctrl *Controller) HandleRandomUUID(c *gin.Context) {
+	count, ok := queryInt(c, "count", 1, 1, maxRandomUUIDs)
+	if !ok {
+		return
+	}
+	r, ok := ctrl.drawRandom(c, int(count)*16)
+	if !ok {
+		return
+	}
+	ids := make([]uuid.UUID, count)
+	for i := range ids {
+		id, err := uuid.NewRandomFromReader(r)
+		if err != nil {
+			respondRandomError(c, err)
+			return
+		}
+		ids[i] = id
+	}
+	c.JSON(http.StatusOK, gin.H{"uuids": ids})
+}
+
+// HandleRandomShuffle returns the given items in a uniformly random order
+func (ctrl *Controller) HandleRandomShuffle(c *gin.Context) {
+	var req struct {
+		Items []json.RawMessage `json:"items" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Items) > maxRandomItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many items (max 1000)"})
+		return
+	}
+
+	r, ok := ctrl.drawRandom(c, len(req.Items)*8)
+	if !ok {
+		return
+	}
+	// Fisher-Yates
+	for i := len(req.Items) - 1; i > 0; i-- {
+		j, err := randomBelow(r, uint64(i+1))
+		if err != nil {
+			respondRandomError(c, err)
+			return
+		}
+		req.Items[i], req.Items[j] = req.Items[j], req.Items[i]
+	}
+	c.JSON(http.StatusOK, gin.H{"items": req.Items})
+}
+
+// HandleRandomChoice picks count items (with replacement), weighted by
+// weights if given and uniformly otherwise
+func (ctrl *Controller) HandleRandomChoice(c *gin.Context) {
+	var req struct {
+		Items   []json.RawMessage `json:"items" binding:"required"`
+		Weights []float64         `json:"weights"`
+		Count   int               `json:"count"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Items) == 0 || len(req.Items) > maxRandomItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide between 1 and 1000 items"})
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 0 || req.Count > maxRandomCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+		return
+	}
+	if req.Weights != nil && len(req.Weights) != len(req.Items) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weights must have one entry per item"})
+		return
+	}
+	total := 0.0
+	for _, w := range req.Weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weights must be finite and non-negative"})
+			return
+		}
+		total += w
+	}
+	if req.Weights != nil && (total <= 0 || math.IsInf(total, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weights must have a positive, finite sum"})
+		return
+	}
+
+	r, ok := ctrl.drawRandom(c, req.Count*8)
+	if !ok {
+		return
+	}
+	indices := make([]int, req.Count)
+	choices := make([]json.RawMessage, req.Count)
+	for n := range indices {
+		var pick int
+		if req.Weights == nil {
+			v, err := randomBelow(r, uint64(len(req.Items)))
+			if err != nil {
+				respondRandomError(c, err)
+				return
+			}
+			pick = int(v)
+		} else {
+			u, err := randomFloat(r)
+			if err != nil {
+				respondRandomError(c, err)
+				return
+			}
+			// Walk the cumulative weights; the last positive weight catches
+			// any rounding left over at the top
+			target := u * total
+			for i, w := range req.Weights {
+				if w == 0 {
+					continue
+				}
+				pick = i
+				if target < w {
+					break
+				}
+				target -= w
+			}
+		}
+		indices[n], choices[n] = pick, req.Items[pick]
+	}
+	c.JSON(http.StatusOK, gin.H{"indices": indices, "choices": choices})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// rateLimitSubject identifies who a request counts against for a scope
+func rateLimitSubject(c *gin.Context, scope string) string {
+	if scope == config.ScopeUser {
+		if v, ok := c.Get("user_id"); ok {
+			if id, ok := v.(uuid.UUID); ok {
+				return "user:" + id.String()
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds rounds up so clients never retry a moment too early
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// RateLimit enforces the named policy from RateLimitConfig. Several policies
+// can apply to one request (e.g. global, api and generate); the RateLimit-*
+// headers describe whichever has the fewest requests remaining.
+func (ctrl *Controller) RateLimit(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ctrl.takeRateLimit(c, name, 1) {
+			c.Next()
+		}
+	}
+}
+
+// takeRateLimit takes cost tokens from the named policy's bucket, responding
+// 429 and returning false when there aren't enough. Handlers call it directly
+// for quotas measured in something other than requests.
+func (ctrl *Controller) takeRateLimit(c *gin.Context, name string, cost int) bool {
+	if ctrl.RateLimits == nil || !ctrl.RateLimits.Enabled || ctrl.Limiter == nil {
+		return true
+	}
+	policy, ok := ctrl.RateLimits.Policies[name]
+	if !ok {
+		log.Printf("Rate limit: unknown policy %q", name)
+		return true
+	}
+
+	key := "rl:" + name + ":" + rateLimitSubject(c, policy.Scope)
+	res, err := ctrl.Limiter.AllowN(c.Request.Context(), key, cost, policy.Limit, policy.Period)
+	if err != nil {
+		// Fail open: a limiter outage shouldn't take the API down with it
+		log.Printf("Rate limit: %s: %v", key, err)
+		return true
+	}
+
+	if prev, exists := c.Get("ratelimit_remaining"); !exists || res.Remaining <= prev.(int) || !res.Allowed {
+		c.Set("ratelimit_remaining", res.Remaining)
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(res.ResetAfter))
+		c.Header("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+ceilSeconds(policy.Period))
+	}
+
+	if !res.Allowed {
+		c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many requests",
+			"retry_after": math.Ceil(res.RetryAfter.Seconds()),
+		})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// AuthLockout locks a client out of the wrapped routes after repeated
+// authentication failures (401/403 responses), with the lockout doubling each
+// time. Use ScopeIP for logins and ScopeUser for second-factor checks.
+func (ctrl *Controller) AuthLockout(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ctrl.RateLimits == nil || !ctrl.RateLimits.Enabled || ctrl.Lockouts == nil {
+			c.Next()
+			return
+		}
+
+		key := "lock:" + rateLimitSubject(c, scope)
+		ctx := c.Request.Context()
+		locked, err := ctrl.Lockouts.LockedFor(ctx, key)
+		if err != nil {
+			log.Printf("Lockout: %s: %v", key, err)
+		}
+		if locked > 0 {
+			c.Header("Retry-After", ceilSeconds(locked))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many failed attempts, try again later",
+				"retry_after": math.Ceil(locked.Seconds()),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		switch status := c.Writer.Status(); {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			lock, err := ctrl.Lockouts.Fail(ctx, key)
+			if err != nil {
+				log.Printf("Lockout: %s: %v", key, err)
+			}
+			if lock > 0 {
+				ctrl.audit(c, AuditLockout, models.AuditFailure, "lockout", key, "locked for "+lock.String())
+			}
+		case status >= 200 && status < 300:
+			if err := ctrl.Lockouts.Succeed(ctx, key); err != nil {
+				log.Printf("Lockout: %s: %v", key, err)
+			}
+		}
+	}
+}
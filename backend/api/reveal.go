@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultReauthWindow is used when the controller has no configured window
+const DefaultReauthWindow = 5 * time.Minute
+
+func (ctrl *Controller) reauthWindow() time.Duration {
+	if ctrl.ReauthWindow <= 0 {
+		return DefaultReauthWindow
+	}
+	return ctrl.ReauthWindow
+}
+
+// RequireFreshAuth only lets a request through if the session logged in or
+// stepped up within the re-authentication window. Must run after AuthMiddleware.
+func (ctrl *Controller) RequireFreshAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		window := ctrl.reauthWindow()
+		sessionID, _ := c.Get("session_id")
+		var record models.Session
+		if err := ctrl.DB.First(&record, "id = ?", sessionID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		authAt := record.CreatedAt
+		if record.StepUpAt != nil && record.StepUpAt.After(authAt) {
+			authAt = *record.StepUpAt
+		}
+		if time.Since(authAt) > window {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":           "Re-authentication required",
+				"reauth_required": true,
+				"reauth_window":   window.String(),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// recordSecretAccess logs that plaintext secrets were served
+func (ctrl *Controller) recordSecretAccess(c *gin.Context, action string, entryID *uuid.UUID) error {
+	userIDInterface, _ := c.Get("user_id")
+	sessionIDInterface, _ := c.Get("session_id")
+	return ctrl.DB.Create(&models.SecretAccess{
+		UserID:    userIDInterface.(uuid.UUID),
+		SessionID: sessionIDInterface.(uuid.UUID),
+		EntryID:   entryID,
+		Action:    action,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}).Error
+}
+
+// HandleRevealPassword returns the plaintext password for one entry
+func (ctrl *Controller) HandleRevealPassword(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var entry models.PasswordEntry
+	if err := ctrl.DB.Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctrl.audit(c, AuditPasswordReveal, models.AuditFailure, "password", id.String(), "not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Password not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch password"})
+		return
+	}
+
+	// Refuse to reveal anything we couldn't record
+	if err := ctrl.recordSecretAccess(c, "reveal", &entry.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record access"})
+		return
+	}
+	ctrl.audit(c, AuditPasswordReveal, models.AuditSuccess, "password", entry.ID.String(), "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       entry.ID,
+		"password": entry.Password,
+	})
+}
+
+// HandleExportVault returns every entry (with secrets) and group for the user
+func (ctrl *Controller) HandleExportVault(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var entries []models.PasswordEntry
+	if err := ctrl.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch passwords"})
+		return
+	}
+	var groups []models.VaultGroup
+	if err := ctrl.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		return
+	}
+
+	if err := ctrl.recordSecretAccess(c, "export", nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record access"})
+		return
+	}
+	ctrl.audit(c, AuditVaultExport, models.AuditSuccess, "vault", userID.String(), fmt.Sprintf("%d entries", len(entries)))
+
+	type ExportEntry struct {
+		models.PasswordEntry
+		Password string `json:"password"`
+	}
+	exported := make([]ExportEntry, 0, len(entries))
+	for _, e := range entries {
+		exported = append(exported, ExportEntry{PasswordEntry: e, Password: e.Password})
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="lavalock-export.json"`)
+	c.JSON(http.StatusOK, gin.H{
+		"exported_at": time.Now(),
+		"entries":     exported,
+		"groups":      groups,
+	})
+}
+
+// HandleReauthenticate refreshes the session's auth time with a new ID token
+// from one of the user's linked providers, for users without passkeys or TOTP
+func (ctrl *Controller) HandleReauthenticate(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+	sessionIDInterface, _ := c.Get("session_id")
+	sessionID := sessionIDInterface.(uuid.UUID)
+	provider := c.Param("provider")
+
+	type ReauthRequest struct {
+		IDToken string `json:"id_token" binding:"required"`
+	}
+	var req ReauthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id_token required"})
+		return
+	}
+
+	claims, ok := ctrl.verifyClaims(c, provider, req.IDToken)
+	if !ok {
+		return
+	}
+
+	// A token cached by the client from the original login isn't fresh
+	if time.Since(claims.IssuedAt) > ctrl.reauthWindow() {
+		ctrl.audit(c, AuditReauth, models.AuditFailure, "identity", provider, "stale ID token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ID token is too old, sign in again"})
+		return
+	}
+
+	var count int64
+	ctrl.DB.Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ? AND subject = ?", userID, provider, claims.Subject).
+		Count(&count)
+	if count == 0 {
+		ctrl.audit(c, AuditReauth, models.AuditFailure, "identity", provider, "identity not linked")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token does not belong to this account"})
+		return
+	}
+
+	ctrl.audit(c, AuditReauth, models.AuditSuccess, "identity", provider, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Re-authenticated",
+		"step_up_at": ctrl.markStepUp(sessionID),
+	})
+}
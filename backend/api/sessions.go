@@ -0,0 +1,207 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultSessionTTL is used when the controller has no configured session lifetime
+const DefaultSessionTTL = 7 * 24 * time.Hour
+
+// lastSeenInterval throttles last_seen_at writes from AuthMiddleware
+const lastSeenInterval = time.Minute
+
+// sessionTokenKey is the cookie session key holding the raw session token
+const sessionTokenKey = "session_token"
+
+const maxDeviceNameLen = 100
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// createSessionRecord records a server-side session for the user and returns
+// it with its raw token. deviceName is optional and client-supplied.
+func (ctrl *Controller) createSessionRecord(c *gin.Context, userID uuid.UUID, deviceName string, ttl time.Duration) (*models.Session, string, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if deviceName == "" {
+		deviceName = c.GetHeader("X-Device-Name")
+	}
+	deviceName = strings.TrimSpace(deviceName)
+	if len(deviceName) > maxDeviceNameLen {
+		deviceName = deviceName[:maxDeviceNameLen]
+	}
+
+	// Users with a confirmed authenticator must verify it before the session is usable
+	var totpCount int64
+	if err := ctrl.DB.Model(&models.UserTOTP{}).Where("user_id = ? AND confirmed_at IS NOT NULL", userID).Count(&totpCount).Error; err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	record := models.Session{
+		UserID:              userID,
+		TokenHash:           hashToken(token),
+		DeviceName:          deviceName,
+		IPAddress:           c.ClientIP(),
+		UserAgent:           c.Request.UserAgent(),
+		LastSeenAt:          now,
+		ExpiresAt:           now.Add(ttl),
+		SecondFactorPending: totpCount > 0,
+	}
+	if err := ctrl.DB.Create(&record).Error; err != nil {
+		return nil, "", err
+	}
+	return &record, token, nil
+}
+
+// startSession creates a session record and stores its token in the session cookie
+func (ctrl *Controller) startSession(c *gin.Context, userID uuid.UUID, deviceName string) (*models.Session, error) {
+	ttl := ctrl.SessionTTL
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	record, token, err := ctrl.createSessionRecord(c, userID, deviceName, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	session := sessions.Default(c)
+	session.Clear()
+	session.Set(sessionTokenKey, token)
+	if err := session.Save(); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// loadSession resolves the cookie's session token to an active session record
+func (ctrl *Controller) loadSession(c *gin.Context) (*models.Session, bool) {
+	token, ok := sessions.Default(c).Get(sessionTokenKey).(string)
+	if !ok || token == "" {
+		return nil, false
+	}
+
+	var record models.Session
+	if err := ctrl.DB.Where("token_hash = ?", hashToken(token)).First(&record).Error; err != nil {
+		return nil, false
+	}
+	return ctrl.checkSession(c, &record)
+}
+
+// checkSession rejects revoked or expired sessions and refreshes last_seen_at
+func (ctrl *Controller) checkSession(c *gin.Context, record *models.Session) (*models.Session, bool) {
+	now := time.Now()
+	if !record.Active(now) {
+		return nil, false
+	}
+
+	if now.Sub(record.LastSeenAt) > lastSeenInterval {
+		ctrl.DB.Model(record).Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"ip_address":   c.ClientIP(),
+		})
+	}
+	return record, true
+}
+
+// revokeSession ends a session and every refresh token issued for it
+func (ctrl *Controller) revokeSession(tx *gorm.DB, sessionID uuid.UUID) error {
+	now := time.Now()
+	if err := tx.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", sessionID).Update("revoked_at", &now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshToken{}).Where("session_id = ? AND revoked_at IS NULL", sessionID).Update("revoked_at", &now).Error
+}
+
+// HandleLogout revokes the current session and clears the cookie
+func (ctrl *Controller) HandleLogout(c *gin.Context) {
+	if sid, exists := c.Get("session_id"); exists {
+		ctrl.revokeSession(ctrl.DB, sid.(uuid.UUID))
+		ctrl.audit(c, AuditLogout, models.AuditSuccess, "session", sid.(uuid.UUID).String(), "")
+	}
+
+	session := sessions.Default(c)
+	session.Clear()
+	session.Save()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// HandleListSessions returns the user's active sessions (devices)
+func (ctrl *Controller) HandleListSessions(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+	currentID, _ := c.Get("session_id")
+
+	var records []models.Session
+	if err := ctrl.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	type ResponseSession struct {
+		models.Session
+		Current bool `json:"current"`
+	}
+	response := make([]ResponseSession, 0, len(records))
+	for _, s := range records {
+		response = append(response, ResponseSession{Session: s, Current: s.ID == currentID})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleRevokeSession signs out one of the user's devices
+func (ctrl *Controller) HandleRevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Session ID format"})
+		return
+	}
+
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var record models.Session
+	if err := ctrl.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).First(&record).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err := ctrl.revokeSession(ctrl.DB, record.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	ctrl.audit(c, AuditSessionRevoke, models.AuditSuccess, "session", record.ID.String(), record.DeviceName)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
@@ -0,0 +1,398 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sync object types
+const (
+	SyncTypeEntry = "entry"
+	SyncTypeGroup = "group"
+)
+
+const syncPageSize = 500
+
+// Tombstone marks an object deleted at a given revision
+type Tombstone struct {
+	Type     string    `json:"type"`
+	ID       uuid.UUID `json:"id"`
+	Revision int64     `json:"revision"`
+}
+
+// HandleSyncPull returns entries, groups and tombstones changed since ?cursor=N.
+// The returned cursor is passed back on the next pull; has_more means call again.
+func (ctrl *Controller) HandleSyncPull(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	var cursor int64
+	if raw := c.Query("cursor"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		cursor = parsed
+	}
+
+	// Read both tables from one snapshot, so a write committing between the
+	// two queries can't put a group past an entry this pull didn't see
+	var entries []models.PasswordEntry
+	var groups []models.VaultGroup
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("user_id = ? AND revision > ?", userID, cursor).
+			Order("revision asc").Limit(syncPageSize + 1).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("user_id = ? AND revision > ?", userID, cursor).
+			Order("revision asc").Limit(syncPageSize + 1).
+			Find(&groups).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes"})
+		return
+	}
+
+	// If either table was truncated, only return changes up to the lower of the two
+	// truncation points so the next pull resumes without skipping anything.
+	limit := int64(-1)
+	hasMore := false
+	if len(entries) > syncPageSize {
+		entries = entries[:syncPageSize]
+		limit = entries[len(entries)-1].Revision
+		hasMore = true
+	}
+	if len(groups) > syncPageSize {
+		groups = groups[:syncPageSize]
+		if last := groups[len(groups)-1].Revision; limit < 0 || last < limit {
+			limit = last
+		}
+		hasMore = true
+	}
+
+	newCursor := cursor
+	liveEntries := []models.PasswordEntry{}
+	liveGroups := []models.VaultGroup{}
+	tombstones := []Tombstone{}
+
+	for _, e := range entries {
+		if limit >= 0 && e.Revision > limit {
+			break
+		}
+		if e.Revision > newCursor {
+			newCursor = e.Revision
+		}
+		if e.DeletedAt.Valid {
+			tombstones = append(tombstones, Tombstone{Type: SyncTypeEntry, ID: e.ID, Revision: e.Revision})
+			continue
+		}
+		liveEntries = append(liveEntries, e)
+	}
+	for _, g := range groups {
+		if limit >= 0 && g.Revision > limit {
+			break
+		}
+		if g.Revision > newCursor {
+			newCursor = g.Revision
+		}
+		if g.DeletedAt.Valid {
+			tombstones = append(tombstones, Tombstone{Type: SyncTypeGroup, ID: g.ID, Revision: g.Revision})
+			continue
+		}
+		liveGroups = append(liveGroups, g)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cursor":     newCursor,
+		"has_more":   hasMore,
+		"entries":    liveEntries,
+		"groups":     liveGroups,
+		"tombstones": tombstones,
+	})
+}
+
+// SyncChange is one offline edit pushed by a client.
+// BaseRevision is the revision the client last saw (0 for objects created offline).
+type SyncChange struct {
+	Type         string    `json:"type"`
+	ID           uuid.UUID `json:"id"`
+	BaseRevision int64     `json:"base_revision"`
+	Deleted      bool      `json:"deleted"`
+
+	// Entry fields. Pulls never include passwords, so Password is only
+	// sent when the client changed it; nil keeps the stored one.
+	Password   *string    `json:"password"`
+	GroupID    *uuid.UUID `json:"group_id"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	WebsiteURL string     `json:"website_url"`
+	Favorite   bool       `json:"favorite"`
+	Tags       []string   `json:"tags"`
+
+	// Group fields
+	Icon  string `json:"icon"`
+	Color string `json:"color"`
+}
+
+// SyncApplied reports the new revision of an accepted change
+type SyncApplied struct {
+	Type     string    `json:"type"`
+	ID       uuid.UUID `json:"id"`
+	Revision int64     `json:"revision"`
+}
+
+// SyncConflict reports a change that was rejected. Server holds the current
+// server-side object (nil if it no longer exists) for the client to resolve against.
+type SyncConflict struct {
+	Type         string      `json:"type"`
+	ID           uuid.UUID   `json:"id"`
+	BaseRevision int64       `json:"base_revision"`
+	Reason       string      `json:"reason"`
+	Server       interface{} `json:"server,omitempty"`
+}
+
+// HandleSyncPush applies offline edits. Each change is accepted only if its
+// base_revision still matches the server; otherwise it is returned as a conflict.
+func (ctrl *Controller) HandleSyncPush(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID := userIDInterface.(uuid.UUID)
+
+	type PushRequest struct {
+		Changes []SyncChange `json:"changes"`
+	}
+	var req PushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Changes) > syncPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many changes"})
+		return
+	}
+
+	// Apply groups first so entries pushed in the same batch can reference them
+	ordered := make([]SyncChange, 0, len(req.Changes))
+	for _, ch := range req.Changes {
+		if ch.Type == SyncTypeGroup {
+			ordered = append(ordered, ch)
+		}
+	}
+	for _, ch := range req.Changes {
+		if ch.Type != SyncTypeGroup {
+			ordered = append(ordered, ch)
+		}
+	}
+
+	applied := []SyncApplied{}
+	conflicts := []SyncConflict{}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		for i, ch := range ordered {
+			savepoint := "sync_change_" + strconv.Itoa(i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			var (
+				rev      int64
+				conflict *SyncConflict
+				err      error
+			)
+			switch ch.Type {
+			case SyncTypeEntry:
+				rev, conflict, err = ctrl.applyEntryChange(tx, userID, ch)
+			case SyncTypeGroup:
+				rev, conflict, err = applyGroupChange(tx, userID, ch)
+			default:
+				conflict = &SyncConflict{Type: ch.Type, ID: ch.ID, BaseRevision: ch.BaseRevision, Reason: "unknown type"}
+			}
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				conflicts = append(conflicts, *conflict)
+				continue
+			}
+			applied = append(applied, SyncApplied{Type: ch.Type, ID: ch.ID, Revision: rev})
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply changes"})
+		return
+	}
+	if len(applied) > 0 {
+		ids := make([]uuid.UUID, 0, len(applied))
+		for _, a := range applied {
+			ids = append(ids, a.ID)
+		}
+		ctrl.notifyVaultChanged(userID, "synced", ids...)
+	}
+	ctrl.audit(c, AuditVaultSync, models.AuditSuccess, "vault", userID.String(), fmt.Sprintf("%d applied, %d conflicts", len(applied), len(conflicts)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"applied":   applied,
+		"conflicts": conflicts,
+	})
+}
+
+func (ctrl *Controller) applyEntryChange(tx *gorm.DB, userID uuid.UUID, ch SyncChange) (int64, *SyncConflict, error) {
+	conflict := func(reason string, server interface{}) *SyncConflict {
+		return &SyncConflict{Type: SyncTypeEntry, ID: ch.ID, BaseRevision: ch.BaseRevision, Reason: reason, Server: server}
+	}
+	if ch.ID == uuid.Nil {
+		return 0, conflict("id required", nil), nil
+	}
+
+	var entry models.PasswordEntry
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", ch.ID).First(&entry).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil, err
+	}
+
+	if found && (entry.UserID == nil || *entry.UserID != userID) {
+		return 0, conflict("id in use", nil), nil
+	}
+	if !found && ch.BaseRevision != 0 {
+		return 0, conflict("not found", nil), nil
+	}
+	if found && entry.Revision != ch.BaseRevision {
+		if entry.DeletedAt.Valid {
+			return 0, conflict("deleted on server", Tombstone{Type: SyncTypeEntry, ID: entry.ID, Revision: entry.Revision}), nil
+		}
+		return 0, conflict("revision mismatch", entry), nil
+	}
+
+	if ch.Deleted {
+		if !found {
+			return 0, conflict("not found", nil), nil
+		}
+		if err := tx.Delete(&entry).Error; err != nil {
+			return 0, nil, err
+		}
+		return currentRevision(tx, &models.PasswordEntry{}, ch.ID)
+	}
+
+	if err := validateGroupID(tx, userID, ch.GroupID); err != nil {
+		if errors.Is(err, ErrGroupNotFound) {
+			return 0, conflict("invalid group_id", nil), nil
+		}
+		return 0, nil, err
+	}
+
+	entry.ID = ch.ID
+	entry.UserID = &userID
+	entry.GroupID = ch.GroupID
+	if ch.Password != nil {
+		entry.Password = *ch.Password
+		entry.EntropyScore = ctrl.KeyGenService.CalculateEntropyEstimate(*ch.Password)
+	}
+	entry.Name = ch.Name
+	entry.Username = ch.Username
+	entry.WebsiteURL = ch.WebsiteURL
+	entry.Favorite = ch.Favorite
+	entry.Tags = normalizeTags(ch.Tags)
+	entry.DeletedAt = gorm.DeletedAt{}
+
+	if found {
+		err = tx.Unscoped().Save(&entry).Error
+	} else {
+		err = tx.Create(&entry).Error
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return currentRevision(tx, &models.PasswordEntry{}, ch.ID)
+}
+
+func applyGroupChange(tx *gorm.DB, userID uuid.UUID, ch SyncChange) (int64, *SyncConflict, error) {
+	conflict := func(reason string, server interface{}) *SyncConflict {
+		return &SyncConflict{Type: SyncTypeGroup, ID: ch.ID, BaseRevision: ch.BaseRevision, Reason: reason, Server: server}
+	}
+	if ch.ID == uuid.Nil {
+		return 0, conflict("id required", nil), nil
+	}
+
+	var group models.VaultGroup
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", ch.ID).First(&group).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil, err
+	}
+
+	if found && group.UserID != userID {
+		return 0, conflict("id in use", nil), nil
+	}
+	if !found && ch.BaseRevision != 0 {
+		return 0, conflict("not found", nil), nil
+	}
+	if found && group.Revision != ch.BaseRevision {
+		if group.DeletedAt.Valid {
+			return 0, conflict("deleted on server", Tombstone{Type: SyncTypeGroup, ID: group.ID, Revision: group.Revision}), nil
+		}
+		return 0, conflict("revision mismatch", group), nil
+	}
+
+	if ch.Deleted {
+		if !found {
+			return 0, conflict("not found", nil), nil
+		}
+		if err := tx.Delete(&group).Error; err != nil {
+			return 0, nil, err
+		}
+		// Same behaviour as HandleDeleteGroup: entries fall back to "All"
+		if err := tx.Model(&models.PasswordEntry{}).Where("group_id = ? AND user_id = ?", ch.ID, userID).Update("group_id", nil).Error; err != nil {
+			return 0, nil, err
+		}
+		return currentRevision(tx, &models.VaultGroup{}, ch.ID)
+	}
+
+	group.ID = ch.ID
+	group.UserID = userID
+	group.Name = ch.Name
+	group.Icon = ch.Icon
+	group.Color = ch.Color
+	group.DeletedAt = gorm.DeletedAt{}
+
+	if found {
+		err = tx.Unscoped().Save(&group).Error
+	} else {
+		err = tx.Create(&group).Error
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return currentRevision(tx, &models.VaultGroup{}, ch.ID)
+}
+
+// currentRevision reads back the trigger-assigned revision for a row
+func currentRevision(tx *gorm.DB, model interface{}, id uuid.UUID) (int64, *SyncConflict, error) {
+	var rev int64
+	err := tx.Unscoped().Model(model).Where("id = ?", id).Select("revision").Scan(&rev).Error
+	return rev, nil, err
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errRefreshReused = errors.New("refresh token reused")
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
+
+// loadBearerSession verifies an access token and the session it belongs to
+func (ctrl *Controller) loadBearerSession(c *gin.Context, token string) (*models.Session, bool) {
+	if ctrl.Tokens == nil {
+		return nil, false
+	}
+	claims, err := ctrl.Tokens.ParseAccessToken(token)
+	if err != nil {
+		return nil, false
+	}
+
+	var record models.Session
+	if err := ctrl.DB.Where("id = ? AND user_id = ?", claims.SessionID, claims.Subject).First(&record).Error; err != nil {
+		return nil, false
+	}
+	return ctrl.checkSession(c, &record)
+}
+
+// issueTokenPair creates an access token and a new refresh token for a session
+func (ctrl *Controller) issueTokenPair(tx *gorm.DB, record *models.Session) (gin.H, error) {
+	access, accessExp, err := ctrl.Tokens.IssueAccessToken(record.UserID, record.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExp := record.ExpiresAt
+	if err := tx.Create(&models.RefreshToken{
+		UserID:    record.UserID,
+		SessionID: record.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: refreshExp,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token_type":         "Bearer",
+		"access_token":       access,
+		"expires_at":         accessExp,
+		"refresh_token":      refresh,
+		"refresh_expires_at": refreshExp,
+	}, nil
+}
+
+// HandleGoogleTokenLogin verifies a Google ID token and returns bearer tokens
+// instead of setting a session cookie
+func (ctrl *Controller) HandleGoogleTokenLogin(c *gin.Context) {
+	ctrl.tokenLoginWithProvider(c, "google")
+}
+
+// HandleOIDCTokenLogin is HandleGoogleTokenLogin for any configured provider
+func (ctrl *Controller) HandleOIDCTokenLogin(c *gin.Context) {
+	ctrl.tokenLoginWithProvider(c, c.Param("provider"))
+}
+
+func (ctrl *Controller) tokenLoginWithProvider(c *gin.Context, provider string) {
+	var req GoogleAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id_token required"})
+		return
+	}
+	if ctrl.Tokens == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token service not initialized"})
+		return
+	}
+
+	user, ok := ctrl.verifyIdentity(c, provider, req.IDToken)
+	if !ok {
+		ctrl.auditAs(c, nil, AuditLogin, models.AuditFailure, "identity", provider, "")
+		return
+	}
+
+	// Token sessions live as long as their refresh chain and slide on each refresh
+	record, _, err := ctrl.createSessionRecord(c, user.ID, req.DeviceName, ctrl.Tokens.RefreshTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	tokens, err := ctrl.issueTokenPair(ctrl.DB, record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue tokens"})
+		return
+	}
+
+	ctrl.auditAs(c, &user.ID, AuditLogin, models.AuditSuccess, "session", record.ID.String(), provider+" (token)")
+
+	tokens["user"] = user
+	tokens["second_factor_required"] = record.SecondFactorPending
+	c.JSON(http.StatusOK, tokens)
+}
+
+// HandleTokenRefresh rotates a refresh token. Each refresh token is single use;
+// presenting one twice revokes the whole session, since it means it was copied.
+func (ctrl *Controller) HandleTokenRefresh(c *gin.Context) {
+	type RefreshRequest struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token required"})
+		return
+	}
+	if ctrl.Tokens == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token service not initialized"})
+		return
+	}
+
+	var tokens gin.H
+	var reusedSession, reusedUser uuid.UUID
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(req.RefreshToken)).
+			First(&stored).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if stored.UsedAt != nil {
+			reusedSession, reusedUser = stored.SessionID, stored.UserID
+			return errRefreshReused
+		}
+		if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+			return gorm.ErrRecordNotFound
+		}
+
+		var record models.Session
+		if err := tx.Where("id = ?", stored.SessionID).First(&record).Error; err != nil {
+			return err
+		}
+		if !record.Active(now) {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&stored).Update("used_at", &now).Error; err != nil {
+			return err
+		}
+		record.ExpiresAt = now.Add(ctrl.Tokens.RefreshTTL)
+		if err := tx.Model(&record).Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"ip_address":   c.ClientIP(),
+			"expires_at":   record.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = ctrl.issueTokenPair(tx, &record)
+		return err
+	})
+
+	switch {
+	case errors.Is(err, errRefreshReused):
+		// Revoke outside the rolled-back transaction so it sticks
+		ctrl.revokeSession(ctrl.DB, reusedSession)
+		ctrl.auditAs(c, &reusedUser, AuditTokenRefresh, models.AuditFailure, "session", reusedSession.String(), "refresh token reuse, session revoked")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, session revoked"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+	default:
+		c.JSON(http.StatusOK, tokens)
+	}
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	totpIssuer         = "LavaLock"
+	totpSecretLen      = 20 // 160 bits, as recommended by RFC 4226
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var errInvalidSecondFactor = errors.New("invalid code")
+
+// newRecoveryCodes returns fresh codes formatted as xxxxx-xxxxx
+func newRecoveryCodes() ([]string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:recoveryCodeLength]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// replaceRecoveryCodes discards the user's old codes and stores new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if err := tx.Create(&models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery
+// code. Confirmed-only unless includePending is set (used while enrolling).
+func checkSecondFactor(tx *gorm.DB, userID uuid.UUID, code, recoveryCode string, includePending bool) error {
+	now := time.Now()
+
+	if code != "" {
+		var totp models.UserTOTP
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID)
+		if !includePending {
+			query = query.Where("confirmed_at IS NOT NULL")
+		}
+		if err := query.First(&totp).Error; err != nil {
+			return errInvalidSecondFactor
+		}
+		step, ok := services.ValidateTOTP(totp.Secret, strings.TrimSpace(code), now, totp.LastUsedStep)
+		if !ok {
+			return errInvalidSecondFactor
+		}
+		return tx.Model(&totp).Update("last_used_step", step).Error
+	}
+
+	if recoveryCode != "" {
+		result := tx.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(recoveryCode))).
+			Update("used_at", &now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidSecondFactor
+		}
+		return nil
+	}
+
+	return errInvalidSecondFactor
+}
+
+// SecondFactorRequest carries either a TOTP code or a recovery code
+type SecondFactorRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// secondFactorMethod names the factor used, for the audit log
+func secondFactorMethod(req SecondFactorRequest) string {
+	if req.Code != "" {
+		return "totp"
+	}
+	return "recovery_code"
+}
+
+// HandleTwoFactorStatus reports whether two-factor is enabled for the user
+func (ctrl *Controller) HandleTwoFactorStatus(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var totp models.UserTOTP
+	enabled := ctrl.DB.Where("user_id = ? AND confirmed_at IS NOT NULL", userID).First(&totp).Error == nil
+
+	var remaining int64
+	ctrl.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining)
+
+	c.JSON(http.StatusOK, gin.H{
+		"totp_enabled":             enabled,
+		"confirmed_at":             totp.ConfirmedAt,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// HandleTOTPEnroll creates a pending TOTP secret derived from lava lamp entropy
+func (ctrl *Controller) HandleTOTPEnroll(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var existing models.UserTOTP
+	if err := ctrl.DB.Where("user_id = ? AND confirmed_at IS NOT NULL", userID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor is already enabled"})
+		return
+	}
+
+	var user models.User
+	if err := ctrl.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+
+	// 1. Find and download the latest lava lamp frame
+	_, imgData, err := ctrl.latestFrame()
+	if err != nil {
+		ctrl.respondFrameError(c, err)
+		return
+	}
+
+	// 2. Derive the secret
+	mixed, err := ctrl.mixedEntropy(32)
+	if err != nil {
+		ctrl.respondFrameError(c, err)
+		return
+	}
+	secret, err := ctrl.KeyGenService.DeriveSecret(services.KeyedSeed("lavalock-totp", mixed, imgData, ctrl.fleetDigest()), totpSecretLen)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to derive secret"})
+		return
+	}
+
+	// 3. Store as pending (replacing any earlier unconfirmed attempt)
+	totp := models.UserTOTP{UserID: userID, Secret: secret}
+	if err := ctrl.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&totp).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save secret"})
+		return
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.ID.String()
+	}
+	uri := services.TOTPURI(totpIssuer, account, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render QR code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"otpauth_uri": uri,
+		"secret":      services.EncodeTOTPSecret(secret),
+		"qr_png":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// HandleTOTPConfirm activates a pending secret and returns one-time recovery codes
+func (ctrl *Controller) HandleTOTPConfirm(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var req SecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code required"})
+		return
+	}
+
+	var codes []string
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSecondFactor(tx, userID, req.Code, "", true); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Model(&models.UserTOTP{}).Where("user_id = ?", userID).Update("confirmed_at", &now).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	ctrl.audit(c, AuditTOTPEnable, auditResult(err), "user", userID.String(), "")
+	if errors.Is(err, errInvalidSecondFactor) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// HandleSecondFactorVerify completes a login that is waiting for a second factor
+func (ctrl *Controller) HandleSecondFactorVerify(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+	sessionIDInterface, _ := c.Get("session_id")
+	sessionID := sessionIDInterface.(uuid.UUID)
+
+	var req SecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code required"})
+		return
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		return checkSecondFactor(tx, userID, req.Code, req.RecoveryCode, false)
+	})
+	ctrl.audit(c, AuditSecondFactor, auditResult(err), "session", sessionID.String(), secondFactorMethod(req))
+	if errors.Is(err, errInvalidSecondFactor) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Verified",
+		"step_up_at": ctrl.markStepUp(sessionID),
+	})
+}
+
+// HandleRegenerateRecoveryCodes replaces the user's recovery codes
+func (ctrl *Controller) HandleRegenerateRecoveryCodes(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var req SecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code required"})
+		return
+	}
+
+	var codes []string
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSecondFactor(tx, userID, req.Code, "", false); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	ctrl.audit(c, AuditRecoveryCodes, auditResult(err), "user", userID.String(), "")
+	if errors.Is(err, errInvalidSecondFactor) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// HandleTOTPDisable turns off two-factor after verifying a current code
+func (ctrl *Controller) HandleTOTPDisable(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var req SecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code required"})
+		return
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSecondFactor(tx, userID, req.Code, req.RecoveryCode, false); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+	ctrl.audit(c, AuditTOTPDisable, auditResult(err), "user", userID.String(), secondFactorMethod(req))
+	if errors.Is(err, errInvalidSecondFactor) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor disabled"})
+}
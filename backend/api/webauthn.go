@@ -0,0 +1,546 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// Ceremony purposes stored with a WebAuthnChallenge
+const (
+	passkeyPurposeRegister = "register"
+	passkeyPurposeLogin    = "login"
+	passkeyPurposeStepUp   = "step_up"
+)
+
+const maxPasskeyNameLen = 64
+
+// NewPasskeys builds the WebAuthn relying party from config
+func NewPasskeys(cfg *config.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:                  cfg.RPID,
+		RPDisplayName:         cfg.RPName,
+		RPOrigins:             cfg.RPOrigins,
+		AttestationPreference: protocol.ConveyancePreference(cfg.Attestation),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: passkeyUV(cfg),
+		},
+	})
+}
+
+func passkeyUV(cfg *config.WebAuthnConfig) protocol.UserVerificationRequirement {
+	if cfg.RequireUserVerification {
+		return protocol.VerificationRequired
+	}
+	return protocol.VerificationPreferred
+}
+
+// passkeyUser adapts a user and their credentials to webauthn.User.
+// The user handle is the raw user UUID, which reveals nothing about the account.
+type passkeyUser struct {
+	user  models.User
+	creds []models.WebAuthnCredential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	id := u.user.ID
+	return id[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	if u.user.Email != "" {
+		return u.user.Email
+	}
+	return u.user.ID.String()
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+	return u.WebAuthnName()
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	out := make([]webauthn.Credential, 0, len(u.creds))
+	for _, c := range u.creds {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, t := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+		out = append(out, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(c.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: uint32(c.SignCount),
+			},
+		})
+	}
+	return out
+}
+
+func (ctrl *Controller) loadPasskeyUser(userID uuid.UUID) (*passkeyUser, error) {
+	u := &passkeyUser{}
+	if err := ctrl.DB.First(&u.user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	if err := ctrl.DB.Where("user_id = ?", userID).Find(&u.creds).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// passkeysEnabled writes a 404 and returns false if WebAuthn isn't configured
+func (ctrl *Controller) passkeysEnabled(c *gin.Context) bool {
+	if ctrl.Passkeys == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passkeys are not configured"})
+		return false
+	}
+	return true
+}
+
+// saveChallenge persists ceremony state and returns the id the client echoes back
+func (ctrl *Controller) saveChallenge(purpose string, userID, sessionID *uuid.UUID, data *webauthn.SessionData) (uuid.UUID, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Opportunistic cleanup of abandoned ceremonies
+	ctrl.DB.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnChallenge{})
+
+	expires := data.Expires
+	if expires.IsZero() {
+		expires = time.Now().Add(5 * time.Minute)
+	}
+	challenge := models.WebAuthnChallenge{
+		UserID:    userID,
+		SessionID: sessionID,
+		Purpose:   purpose,
+		Data:      string(raw),
+		ExpiresAt: expires,
+	}
+	if err := ctrl.DB.Create(&challenge).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return challenge.ID, nil
+}
+
+// takeChallenge loads and deletes a challenge so it can only be used once
+func (ctrl *Controller) takeChallenge(c *gin.Context, purpose string) (*models.WebAuthnChallenge, *webauthn.SessionData, bool) {
+	id, err := uuid.Parse(c.Query("challenge_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "challenge_id required"})
+		return nil, nil, false
+	}
+
+	var challenge models.WebAuthnChallenge
+	// DELETE ... RETURNING makes consuming the challenge atomic
+	result := ctrl.DB.Clauses(clause.Returning{}).Where("id = ? AND purpose = ? AND expires_at > ?", id, purpose, time.Now()).
+		Delete(&challenge)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge expired or not found"})
+		return nil, nil, false
+	}
+
+	var data webauthn.SessionData
+	if err := json.Unmarshal([]byte(challenge.Data), &data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Corrupt challenge"})
+		return nil, nil, false
+	}
+	return &challenge, &data, true
+}
+
+// recordAssertion persists the new sign counter and rejects cloned authenticators
+func (ctrl *Controller) recordAssertion(cred *webauthn.Credential) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"sign_count":    int64(cred.Authenticator.SignCount),
+		"clone_warning": cred.Authenticator.CloneWarning,
+		"flags":         uint8(cred.Flags.ProtocolValue()),
+		"last_used_at":  &now,
+	}
+	if err := ctrl.DB.Model(&models.WebAuthnCredential{}).
+		Where("credential_id = ?", cred.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if cred.Authenticator.CloneWarning {
+		return errors.New("authenticator sign counter went backwards, possible cloned credential")
+	}
+	return nil
+}
+
+// HandlePasskeyRegisterBegin starts registering a passkey for the current user
+func (ctrl *Controller) HandlePasskeyRegisterBegin(c *gin.Context) {
+	if !ctrl.passkeysEnabled(c) {
+		return
+	}
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	user, err := ctrl.loadPasskeyUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.creds))
+	for _, cred := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, cred.Descriptor())
+	}
+
+	options, session, err := ctrl.Passkeys.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start registration"})
+		return
+	}
+	challengeID, err := ctrl.saveChallenge(passkeyPurposeRegister, &userID, nil, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge_id": challengeID,
+		"options":      options,
+	})
+}
+
+// HandlePasskeyRegisterFinish verifies the attestation and stores the credential.
+// The body is the browser's PublicKeyCredential JSON; ?challenge_id= and ?name= are query params.
+func (ctrl *Controller) HandlePasskeyRegisterFinish(c *gin.Context) {
+	if !ctrl.passkeysEnabled(c) {
+		return
+	}
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	challenge, session, ok := ctrl.takeChallenge(c, passkeyPurposeRegister)
+	if !ok {
+		return
+	}
+	if challenge.UserID == nil || *challenge.UserID != userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge expired or not found"})
+		return
+	}
+
+	user, err := ctrl.loadPasskeyUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+
+	cred, err := ctrl.Passkeys.FinishRegistration(user, *session, c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registration failed: " + err.Error()})
+		return
+	}
+
+	// Attestation policy: optionally restrict which statement formats we trust
+	if allowed := ctrl.PasskeyPolicy.AllowedAttestationFormats; len(allowed) > 0 {
+		permitted := false
+		for _, format := range allowed {
+			if strings.EqualFold(format, cred.AttestationType) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Authenticator attestation format not allowed: " + cred.AttestationType})
+			return
+		}
+	}
+
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > maxPasskeyNameLen {
+		name = name[:maxPasskeyNameLen]
+	}
+	transports := make(models.StringList, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transports = append(transports, string(t))
+	}
+
+	record := models.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    cred.ID,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.Authenticator.AAGUID,
+		Transports:      transports,
+		Flags:           uint8(cred.Flags.ProtocolValue()),
+		SignCount:       int64(cred.Authenticator.SignCount),
+	}
+	if err := ctrl.DB.Create(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save passkey"})
+		return
+	}
+	ctrl.audit(c, AuditPasskeyRegister, models.AuditSuccess, "passkey", record.ID.String(), record.Name)
+
+	c.JSON(http.StatusOK, record)
+}
+
+// HandleListPasskeys returns the current user's registered passkeys
+func (ctrl *Controller) HandleListPasskeys(c *gin.Context) {
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	var creds []models.WebAuthnCredential
+	if err := ctrl.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&creds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch passkeys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, creds)
+}
+
+// HandleDeletePasskey removes one of the current user's passkeys
+func (ctrl *Controller) HandleDeletePasskey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Passkey ID format"})
+		return
+	}
+
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+
+	result := ctrl.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete passkey"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+		return
+	}
+	ctrl.audit(c, AuditPasskeyDelete, models.AuditSuccess, "passkey", id.String(), "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey deleted"})
+}
+
+// HandlePasskeyLoginBegin starts a usernameless (discoverable) passkey login
+func (ctrl *Controller) HandlePasskeyLoginBegin(c *gin.Context) {
+	if !ctrl.passkeysEnabled(c) {
+		return
+	}
+
+	options, session, err := ctrl.Passkeys.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(passkeyUV(ctrl.PasskeyPolicy)),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	challengeID, err := ctrl.saveChallenge(passkeyPurposeLogin, nil, nil, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge_id": challengeID,
+		"options":      options,
+	})
+}
+
+// verifyPasskeyLogin finishes a discoverable login and returns the user and
+// whether the authenticator verified them (UV flag). On failure it writes the
+// error response and returns ok == false.
+func (ctrl *Controller) verifyPasskeyLogin(c *gin.Context) (user *models.User, verified, ok bool) {
+	if !ctrl.passkeysEnabled(c) {
+		return nil, false, false
+	}
+	_, session, ok := ctrl.takeChallenge(c, passkeyPurposeLogin)
+	if !ok {
+		return nil, false, false
+	}
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		return ctrl.loadPasskeyUser(userID)
+	}
+
+	found, cred, err := ctrl.Passkeys.FinishPasskeyLogin(handler, *session, c.Request)
+	if err != nil {
+		ctrl.auditAs(c, nil, AuditLogin, models.AuditFailure, "passkey", "", "")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey login failed"})
+		return nil, false, false
+	}
+	userID := found.(*passkeyUser).user.ID
+	if err := ctrl.recordAssertion(cred); err != nil {
+		ctrl.auditAs(c, &userID, AuditLogin, models.AuditFailure, "passkey", "", err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+
+	u := found.(*passkeyUser).user
+	return &u, cred.Flags.UserVerified, true
+}
+
+// markStepUp records a fresh second-factor verification on a session, which
+// also satisfies a pending two-factor login
+func (ctrl *Controller) markStepUp(sessionID uuid.UUID) time.Time {
+	now := time.Now()
+	ctrl.DB.Model(&models.Session{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+		"step_up_at":            &now,
+		"second_factor_pending": false,
+	})
+	return now
+}
+
+// HandlePasskeyLoginFinish completes a passkey login with a session cookie
+func (ctrl *Controller) HandlePasskeyLoginFinish(c *gin.Context) {
+	user, verified, ok := ctrl.verifyPasskeyLogin(c)
+	if !ok {
+		return
+	}
+
+	record, err := ctrl.startSession(c, user.ID, c.Query("device_name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	// A user-verified passkey is already a strong factor; a presence-only
+	// assertion is not
+	if verified {
+		ctrl.markStepUp(record.ID)
+	}
+	ctrl.auditAs(c, &user.ID, AuditLogin, models.AuditSuccess, "session", record.ID.String(), "passkey")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"user":    user,
+	})
+}
+
+// HandlePasskeyTokenLoginFinish completes a passkey login with bearer tokens
+func (ctrl *Controller) HandlePasskeyTokenLoginFinish(c *gin.Context) {
+	if ctrl.Tokens == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token service not initialized"})
+		return
+	}
+	user, verified, ok := ctrl.verifyPasskeyLogin(c)
+	if !ok {
+		return
+	}
+
+	record, _, err := ctrl.createSessionRecord(c, user.ID, c.Query("device_name"), ctrl.Tokens.RefreshTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	if verified {
+		ctrl.markStepUp(record.ID)
+	}
+	ctrl.auditAs(c, &user.ID, AuditLogin, models.AuditSuccess, "session", record.ID.String(), "passkey (token)")
+
+	tokens, err := ctrl.issueTokenPair(ctrl.DB, record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue tokens"})
+		return
+	}
+	tokens["user"] = user
+	c.JSON(http.StatusOK, tokens)
+}
+
+// HandlePasskeyStepUpBegin asks the signed-in user to re-verify with a passkey
+func (ctrl *Controller) HandlePasskeyStepUpBegin(c *gin.Context) {
+	if !ctrl.passkeysEnabled(c) {
+		return
+	}
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+	sessionIDInterface, _ := c.Get("session_id")
+	sessionID := sessionIDInterface.(uuid.UUID)
+
+	user, err := ctrl.loadPasskeyUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+	if len(user.creds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No passkeys registered"})
+		return
+	}
+
+	options, session, err := ctrl.Passkeys.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start verification"})
+		return
+	}
+	challengeID, err := ctrl.saveChallenge(passkeyPurposeStepUp, &userID, &sessionID, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge_id": challengeID,
+		"options":      options,
+	})
+}
+
+// HandlePasskeyStepUpFinish verifies the assertion and marks the session as stepped up
+func (ctrl *Controller) HandlePasskeyStepUpFinish(c *gin.Context) {
+	if !ctrl.passkeysEnabled(c) {
+		return
+	}
+	userIDInterface, _ := c.Get("user_id")
+	userID := userIDInterface.(uuid.UUID)
+	sessionIDInterface, _ := c.Get("session_id")
+	sessionID := sessionIDInterface.(uuid.UUID)
+
+	challenge, session, ok := ctrl.takeChallenge(c, passkeyPurposeStepUp)
+	if !ok {
+		return
+	}
+	if challenge.SessionID == nil || *challenge.SessionID != sessionID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge expired or not found"})
+		return
+	}
+
+	user, err := ctrl.loadPasskeyUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+	cred, err := ctrl.Passkeys.FinishLogin(user, *session, c.Request)
+	if err != nil {
+		ctrl.audit(c, AuditSecondFactor, models.AuditFailure, "session", sessionID.String(), "passkey")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey verification failed"})
+		return
+	}
+	if err := ctrl.recordAssertion(cred); err != nil {
+		ctrl.audit(c, AuditSecondFactor, models.AuditFailure, "session", sessionID.String(), "passkey: "+err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	ctrl.audit(c, AuditSecondFactor, models.AuditSuccess, "session", sessionID.String(), "passkey")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Verified",
+		"step_up_at": ctrl.markStepUp(sessionID),
+	})
+}
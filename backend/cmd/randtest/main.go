@@ -0,0 +1,223 @@
+// Command randtest draws output from the password generator and runs the
+// NIST SP 800-22 statistical tests on it.
+//
+//	go run ./cmd/randtest -source frames -dir ./frames -mbits 1
+//	go run ./cmd/randtest -source frames           # frames in the source bucket
+//	go run ./cmd/randtest -source live -mbits 1    # secrets from the latest frame
+//	go run ./cmd/randtest -source file -in out.bin # any raw binary file
+//
+// For stored frames, each frame is run through the server's password
+// derivation, OutputBytes(PasswordSeed(nonce, image, fleet)), contributing
+// -per-frame bytes. The frame stands in for the AI wallpaper (the Gemini step
+// is skipped) and there is no fleet digest. With -nonce random each frame
+// gets a fresh nonce as in production; the output is then only as weak as
+// HMAC-SHA256, so -nonce zero fixes the nonce to measure what the frames
+// alone contribute, i.e. the output as seen by someone who knows the nonce.
+// Live mode draws from DeriveSecret, which mixes the latest frame with OS
+// randomness as the TOTP enrollment does. The exit status is 1 if any test
+// fails.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	source := flag.String("source", "frames", "where to draw bits from: frames, live or file")
+	mbits := flag.Float64("mbits", 1, "megabits to test")
+	dir := flag.String("dir", "", "directory of JPEG frames (default: the source bucket)")
+	in := flag.String("in", "", "input file for -source file")
+	perFrame := flag.Int("per-frame", 32, "output bytes drawn from each stored frame")
+	nonceMode := flag.String("nonce", "random", "password nonce for stored frames: random or zero")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	godotenv.Load()
+	want := int(*mbits * 1e6 / 8)
+	if want <= 0 || *perFrame <= 0 {
+		log.Fatal("-mbits and -per-frame must be positive")
+	}
+
+	keygen := services.NewKeyGenService()
+	var (
+		data        []byte
+		description string
+		err         error
+	)
+	switch *source {
+	case "frames":
+		if *nonceMode != "random" && *nonceMode != "zero" {
+			log.Fatalf("unknown -nonce %q", *nonceMode)
+		}
+		var frames int
+		data, frames, err = fromFrames(keygen, *dir, want, *perFrame, *nonceMode == "zero")
+		description = fmt.Sprintf("%d stored frames (%s nonce)", frames, *nonceMode)
+	case "live":
+		data, err = fromLive(keygen, want)
+		description = "live secret derivation"
+	case "file":
+		if *in == "" {
+			log.Fatal("-source file needs -in")
+		}
+		data, err = os.ReadFile(*in)
+		if len(data) > want {
+			data = data[:want]
+		}
+		description = *in
+	default:
+		log.Fatalf("unknown -source %q", *source)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(data) < want {
+		log.Printf("Only %d of %d requested bits available", len(data)*8, want*8)
+	}
+
+	bits := services.BytesToBits(data)
+	results := services.RunSP80022(bits)
+	failed := false
+	for _, r := range results {
+		if r.Skipped == "" && !r.Passed {
+			failed = true
+		}
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"source":  description,
+			"bits":    len(bits),
+			"alpha":   services.SP80022Alpha,
+			"passed":  !failed,
+			"results": results,
+		})
+	} else {
+		printReport(description, len(bits), results)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fromFrames reads stored frames from dir, or from the source bucket, and
+// returns the password derivation's output for each until want bytes are
+// collected
+func fromFrames(keygen *services.KeyGenService, dir string, want, perFrame int, zeroNonce bool) ([]byte, int, error) {
+	var (
+		keys []string
+		load func(string) ([]byte, error)
+	)
+	if dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.jp*g"))
+		if err != nil {
+			return nil, 0, err
+		}
+		keys, load = matches, os.ReadFile
+	} else {
+		s3, err := sourceBucket()
+		if err != nil {
+			return nil, 0, err
+		}
+		if keys, err = s3.ListKeys("lava_"); err != nil {
+			return nil, 0, fmt.Errorf("list frames: %w", err)
+		}
+		load = s3.DownloadImage
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return nil, 0, fmt.Errorf("no frames found")
+	}
+
+	var out []byte
+	used := 0
+	for _, key := range keys {
+		if len(out) >= want {
+			break
+		}
+		frame, err := load(key)
+		if err != nil {
+			return nil, used, fmt.Errorf("read %s: %w", key, err)
+		}
+		nonce := make([]byte, services.ProvenanceNonceBytes)
+		if !zeroNonce {
+			if nonce, err = services.NewProvenanceNonce(); err != nil {
+				return nil, used, err
+			}
+		}
+		out = append(out, keygen.OutputBytes(services.PasswordSeed(nonce, frame, nil), perFrame)...)
+		used++
+	}
+	return out[:min(len(out), want)], used, nil
+}
+
+// fromLive derives secrets from the latest frame in the source bucket
+func fromLive(keygen *services.KeyGenService, want int) ([]byte, error) {
+	s3, err := sourceBucket()
+	if err != nil {
+		return nil, err
+	}
+	key, err := s3.GetLatestLavaLampImage()
+	if err != nil {
+		return nil, fmt.Errorf("find latest frame: %w", err)
+	}
+	frame, err := s3.DownloadImage(key)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", key, err)
+	}
+
+	out := make([]byte, 0, want)
+	for len(out) < want {
+		secret, err := keygen.DeriveSecret(frame, 32)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, secret...)
+	}
+	return out[:want], nil
+}
+
+// sourceBucket connects to the bucket the cameras upload to, configured as
+// for the server
+func sourceBucket() (*services.S3Service, error) {
+	bucket := os.Getenv("AWS_BUCKET_NAME")
+	if bucket == "" {
+		bucket = "lava-banana"
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	return services.NewS3Service(region, bucket)
+}
+
+func printReport(description string, bits int, results []services.RandTestResult) {
+	fmt.Printf("NIST SP 800-22 report: %d bits from %s (alpha %.2f)\n\n", bits, description, services.SP80022Alpha)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEST\tP-VALUES\tRESULT")
+	for _, r := range results {
+		pValues := make([]string, len(r.PValues))
+		for i, p := range r.PValues {
+			pValues[i] = fmt.Sprintf("%.6f", p)
+		}
+		result := "PASS"
+		switch {
+		case r.Skipped != "":
+			result = "SKIP (" + r.Skipped + ")"
+		case !r.Passed:
+			result = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, strings.Join(pValues, " "), result)
+	}
+	w.Flush()
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// AccountConfig holds self-service account settings
+type AccountConfig struct {
+	// DeletionGrace is how long a deletion request can be cancelled before
+	// the account and its data are purged (ACCOUNT_DELETION_GRACE)
+	DeletionGrace time.Duration
+}
+
+// LoadAccountConfig reads account settings from the environment
+func LoadAccountConfig() (*AccountConfig, error) {
+	cfg := &AccountConfig{DeletionGrace: 14 * 24 * time.Hour}
+
+	if raw := os.Getenv("ACCOUNT_DELETION_GRACE"); raw != "" {
+		var err error
+		if cfg.DeletionGrace, err = time.ParseDuration(raw); err != nil || cfg.DeletionGrace < 0 {
+			return nil, fmt.Errorf("ACCOUNT_DELETION_GRACE must be a duration (e.g. 336h)")
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BeaconConfig holds settings for the public randomness beacon.
+//
+// BEACON_SIGNING_KEY is a base64 Ed25519 private key, either the 32-byte seed
+// or the 64-byte expanded form. BEACON_TRUSTED_KEYS lists the base64 public
+// keys of earlier signing keys, so pulses signed before a rotation still
+// verify.
+type BeaconConfig struct {
+	Enabled     bool
+	SigningKey  ed25519.PrivateKey
+	TrustedKeys []ed25519.PublicKey
+	Period      time.Duration
+}
+
+// LoadBeaconConfig reads BEACON_ENABLED, BEACON_SIGNING_KEY,
+// BEACON_TRUSTED_KEYS and BEACON_PERIOD (default 1m) from the environment
+func LoadBeaconConfig() (*BeaconConfig, error) {
+	cfg := &BeaconConfig{Enabled: true, Period: time.Minute}
+
+	var err error
+	if raw := os.Getenv("BEACON_ENABLED"); raw != "" {
+		if cfg.Enabled, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("BEACON_ENABLED must be true or false")
+		}
+	}
+	if raw := os.Getenv("BEACON_PERIOD"); raw != "" {
+		if cfg.Period, err = time.ParseDuration(raw); err != nil || cfg.Period < time.Second {
+			return nil, fmt.Errorf("BEACON_PERIOD must be a duration of at least 1s")
+		}
+	}
+
+	for _, entry := range strings.Split(os.Getenv("BEACON_TRUSTED_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pub, err := base64.StdEncoding.DecodeString(entry)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("BEACON_TRUSTED_KEYS entries must be base64 %d-byte public keys", ed25519.PublicKeySize)
+		}
+		cfg.TrustedKeys = append(cfg.TrustedKeys, ed25519.PublicKey(pub))
+	}
+
+	raw := os.Getenv("BEACON_SIGNING_KEY")
+	if raw == "" {
+		if IsProduction() && cfg.Enabled {
+			return nil, fmt.Errorf("BEACON_SIGNING_KEY is required in production")
+		}
+		log.Println("WARNING: BEACON_SIGNING_KEY not set, using a random key (pulses will be signed by a new key after a restart)")
+		cfg.SigningKey = ed25519.NewKeyFromSeed(randomKey(ed25519.SeedSize))
+		return cfg, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("BEACON_SIGNING_KEY is not valid base64")
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		cfg.SigningKey = ed25519.NewKeyFromSeed(key)
+	case ed25519.PrivateKeySize:
+		cfg.SigningKey = ed25519.PrivateKey(key)
+	default:
+		return nil, fmt.Errorf("BEACON_SIGNING_KEY must be a %d-byte seed or %d-byte private key", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+	return cfg, nil
+}
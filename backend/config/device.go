@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// minDeviceKeyLen is the shortest accepted HMAC key for a camera
+const minDeviceKeyLen = 32
+
+// DeviceSeed is a camera registered from the environment
+type DeviceSeed struct {
+	ID  uuid.UUID
+	Key []byte
+}
+
+// LoadDeviceSeeds reads DEVICE_KEYS, a comma-separated list of
+// <device uuid>:<base64 key> pairs. The same values go into the camera's
+// firmware; the device signs each upload with the key.
+func LoadDeviceSeeds() ([]DeviceSeed, error) {
+	var seeds []DeviceSeed
+	for i, item := range splitList(os.Getenv("DEVICE_KEYS")) {
+		idStr, keyStr, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("DEVICE_KEYS[%d]: expected <uuid>:<base64 key>", i)
+		}
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("DEVICE_KEYS[%d]: invalid device id", i)
+		}
+		key, err := base64.StdEncoding.DecodeString(keyStr)
+		if err != nil {
+			return nil, fmt.Errorf("DEVICE_KEYS[%d]: key must be base64", i)
+		}
+		if len(key) < minDeviceKeyLen {
+			return nil, fmt.Errorf("DEVICE_KEYS[%d]: key must be at least %d bytes", i, minDeviceKeyLen)
+		}
+		seeds = append(seeds, DeviceSeed{ID: id, Key: key})
+	}
+	return seeds, nil
+}
+
+// DeviceConfig holds camera health thresholds
+type DeviceConfig struct {
+	// StaleAfter is how long a device may go without a heartbeat
+	StaleAfter time.Duration
+	// MaxClockSkew marks a device degraded when its clock drifts further
+	MaxClockSkew time.Duration
+}
+
+// LoadDeviceConfig reads DEVICE_STALE_AFTER (default 3m) and
+// DEVICE_MAX_CLOCK_SKEW (default 30s)
+func LoadDeviceConfig() (*DeviceConfig, error) {
+	cfg := &DeviceConfig{
+		StaleAfter:   3 * time.Minute,
+		MaxClockSkew: 30 * time.Second,
+	}
+	if raw := os.Getenv("DEVICE_STALE_AFTER"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("DEVICE_STALE_AFTER must be a positive duration")
+		}
+		cfg.StaleAfter = d
+	}
+	if raw := os.Getenv("DEVICE_MAX_CLOCK_SKEW"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("DEVICE_MAX_CLOCK_SKEW must be a positive duration")
+		}
+		cfg.MaxClockSkew = d
+	}
+	return cfg, nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// EntropyHealthConfig tunes the SP 800-90B continuous health tests
+type EntropyHealthConfig struct {
+	// MinEntropy is the assessed min-entropy per noise sample (bits, 0-1
+	// for the one-bit samples taken from frames). It sets the test cutoffs.
+	MinEntropy float64
+	// StartupFrames is how many consecutive frames must pass before the
+	// source is trusted, at startup and again after an alarm
+	StartupFrames int
+}
+
+// LoadEntropyHealthConfig reads ENTROPY_MIN_ENTROPY (default 0.2) and
+// ENTROPY_STARTUP_FRAMES (default 3)
+func LoadEntropyHealthConfig() (*EntropyHealthConfig, error) {
+	cfg := &EntropyHealthConfig{MinEntropy: 0.2, StartupFrames: 3}
+	if raw := os.Getenv("ENTROPY_MIN_ENTROPY"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 || v > 1 {
+			return nil, fmt.Errorf("ENTROPY_MIN_ENTROPY must be in (0, 1]")
+		}
+		cfg.MinEntropy = v
+	}
+	if raw := os.Getenv("ENTROPY_STARTUP_FRAMES"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("ENTROPY_STARTUP_FRAMES must be a positive number")
+		}
+		cfg.StartupFrames = v
+	}
+	return cfg, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// EntropyMixerConfig sets the min-entropy credited to each kind of source
+// and how much the mixer needs before it will produce output
+type EntropyMixerConfig struct {
+	// Threshold is the credited min-entropy (bits) required before output
+	Threshold float64
+	// MaxSourceShare caps any one source's credit at this fraction of the
+	// threshold, so no single source can satisfy it alone
+	MaxSourceShare float64
+	// CameraCredit is credited per distinct accepted frame
+	CameraCredit float64
+	// OSCredit is credited per crypto/rand draw
+	OSCredit float64
+	// WallpaperCredit is credited per AI wallpaper. The wallpaper is derived
+	// from a frame by a third party, so it earns nothing by default.
+	WallpaperCredit float64
+}
+
+// LoadEntropyMixerConfig reads ENTROPY_MIX_THRESHOLD (default 256),
+// ENTROPY_MIX_MAX_SHARE (0.5), ENTROPY_CREDIT_CAMERA (32),
+// ENTROPY_CREDIT_OS (128) and ENTROPY_CREDIT_WALLPAPER (0)
+func LoadEntropyMixerConfig() (*EntropyMixerConfig, error) {
+	cfg := &EntropyMixerConfig{
+		Threshold:      256,
+		MaxSourceShare: 0.5,
+		CameraCredit:   32,
+		OSCredit:       128,
+	}
+	vars := []struct {
+		name string
+		dest *float64
+		max  float64
+	}{
+		{"ENTROPY_MIX_THRESHOLD", &cfg.Threshold, 4096},
+		{"ENTROPY_MIX_MAX_SHARE", &cfg.MaxSourceShare, 1},
+		{"ENTROPY_CREDIT_CAMERA", &cfg.CameraCredit, 256},
+		{"ENTROPY_CREDIT_OS", &cfg.OSCredit, 256},
+		{"ENTROPY_CREDIT_WALLPAPER", &cfg.WallpaperCredit, 256},
+	}
+	for _, v := range vars {
+		raw := os.Getenv(v.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || n < 0 || n > v.max {
+			return nil, fmt.Errorf("%s must be a number between 0 and %g", v.name, v.max)
+		}
+		*v.dest = n
+	}
+	if cfg.Threshold <= 0 || cfg.MaxSourceShare <= 0 {
+		return nil, fmt.Errorf("ENTROPY_MIX_THRESHOLD and ENTROPY_MIX_MAX_SHARE must be positive")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OIDCProviderConfig describes one OpenID Connect identity provider
+type OIDCProviderConfig struct {
+	Name      string
+	Issuer    string
+	ClientIDs []string
+	// ExtraIssuers are additional "iss" values accepted in ID tokens
+	// (Google issues both "https://accounts.google.com" and "accounts.google.com")
+	ExtraIssuers []string
+}
+
+// wellKnownIssuers lets common providers omit OIDC_<NAME>_ISSUER
+var wellKnownIssuers = map[string]string{
+	"google": "https://accounts.google.com",
+	"apple":  "https://appleid.apple.com",
+}
+
+// LoadOIDCProviders reads providers from the environment:
+//
+//	OIDC_PROVIDERS=apple,corp
+//	OIDC_APPLE_CLIENT_IDS=com.example.app
+//	OIDC_CORP_ISSUER=https://sso.example.com
+//	OIDC_CORP_CLIENT_IDS=lavalock
+//
+// Google is registered automatically when GOOGLE_CLIENT_ID is set.
+func LoadOIDCProviders() ([]OIDCProviderConfig, error) {
+	var providers []OIDCProviderConfig
+	seen := map[string]bool{}
+
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		name = strings.ToLower(name)
+		if seen[name] {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		issuer := os.Getenv(prefix + "ISSUER")
+		if issuer == "" {
+			issuer = wellKnownIssuers[name]
+		}
+		if issuer == "" {
+			return nil, fmt.Errorf("%sISSUER is required for provider %q", prefix, name)
+		}
+		clientIDs := splitList(os.Getenv(prefix + "CLIENT_IDS"))
+		if len(clientIDs) == 0 {
+			return nil, fmt.Errorf("%sCLIENT_IDS is required for provider %q", prefix, name)
+		}
+
+		providers = append(providers, withExtraIssuers(OIDCProviderConfig{
+			Name:      name,
+			Issuer:    strings.TrimSuffix(issuer, "/"),
+			ClientIDs: clientIDs,
+		}))
+		seen[name] = true
+	}
+
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" && !seen["google"] {
+		providers = append(providers, withExtraIssuers(OIDCProviderConfig{
+			Name:      "google",
+			Issuer:    wellKnownIssuers["google"],
+			ClientIDs: splitList(clientID),
+		}))
+	}
+
+	return providers, nil
+}
+
+func withExtraIssuers(p OIDCProviderConfig) OIDCProviderConfig {
+	if p.Issuer == wellKnownIssuers["google"] {
+		p.ExtraIssuers = []string{"accounts.google.com"}
+	}
+	return p
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
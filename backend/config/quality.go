@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// FrameQualityConfig holds the thresholds a frame must pass before it is
+// used as entropy. Brightness and contrast are on the 0-255 luma scale.
+type FrameQualityConfig struct {
+	MinBrightness float64 // below this the lens is covered or the lamp is off
+	MaxBrightness float64 // above this the sensor is blown out
+	MinContrast   float64 // luma standard deviation; a flat frame has none
+	// MinDifference is the mean per-pixel change (on a 16x16 thumbnail)
+	// required against the device's previous frame; catches frozen cameras
+	MinDifference float64
+	// MinHashDistance is the smallest perceptual hash distance (bits, 0-64)
+	// allowed to any of the device's RecentFrames accepted frames. 0 disables.
+	MinHashDistance int
+	RecentFrames    int
+}
+
+// LoadFrameQualityConfig reads FRAME_MIN_BRIGHTNESS, FRAME_MAX_BRIGHTNESS,
+// FRAME_MIN_CONTRAST, FRAME_MIN_DIFFERENCE, FRAME_MIN_HASH_DISTANCE and
+// FRAME_RECENT_FRAMES
+func LoadFrameQualityConfig() (*FrameQualityConfig, error) {
+	cfg := &FrameQualityConfig{
+		MinBrightness:   16,
+		MaxBrightness:   240,
+		MinContrast:     8,
+		MinDifference:   0.5,
+		MinHashDistance: 1,
+		RecentFrames:    5,
+	}
+
+	floats := []struct {
+		env string
+		dst *float64
+	}{
+		{"FRAME_MIN_BRIGHTNESS", &cfg.MinBrightness},
+		{"FRAME_MAX_BRIGHTNESS", &cfg.MaxBrightness},
+		{"FRAME_MIN_CONTRAST", &cfg.MinContrast},
+		{"FRAME_MIN_DIFFERENCE", &cfg.MinDifference},
+	}
+	for _, f := range floats {
+		if raw := os.Getenv(f.env); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || v < 0 || v > 255 {
+				return nil, fmt.Errorf("%s must be a number between 0 and 255", f.env)
+			}
+			*f.dst = v
+		}
+	}
+	if cfg.MinBrightness >= cfg.MaxBrightness {
+		return nil, fmt.Errorf("FRAME_MIN_BRIGHTNESS must be below FRAME_MAX_BRIGHTNESS")
+	}
+
+	if raw := os.Getenv("FRAME_MIN_HASH_DISTANCE"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 || v > 64 {
+			return nil, fmt.Errorf("FRAME_MIN_HASH_DISTANCE must be between 0 and 64")
+		}
+		cfg.MinHashDistance = v
+	}
+	if raw := os.Getenv("FRAME_RECENT_FRAMES"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("FRAME_RECENT_FRAMES must be a positive number")
+		}
+		cfg.RecentFrames = v
+	}
+	return cfg, nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limit scopes: who a bucket belongs to
+const (
+	ScopeIP   = "ip"
+	ScopeUser = "user" // falls back to IP for anonymous requests
+)
+
+// RateLimitPolicy allows Limit requests per Period for each key in Scope.
+// Bursts of up to Limit requests are allowed.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Scope  string
+}
+
+// LockoutPolicy locks a key out after Threshold auth failures within Window.
+// Each further lockout doubles from BaseLock up to MaxLock.
+type LockoutPolicy struct {
+	Threshold int
+	Window    time.Duration
+	BaseLock  time.Duration
+	MaxLock   time.Duration
+}
+
+// RateLimitConfig holds the named policies used by ctrl.RateLimit.
+//
+// Each policy can be overridden with RATE_LIMIT_<NAME>=<limit>/<period>,
+// e.g. RATE_LIMIT_GENERATE=10/1h. RATE_LIMIT_BACKEND is memory (single
+// instance) or postgres (shared between instances).
+type RateLimitConfig struct {
+	Enabled  bool
+	Backend  string
+	Policies map[string]RateLimitPolicy
+	Lockout  LockoutPolicy
+}
+
+// defaultRateLimitPolicies are tuned for the mobile app's normal usage
+var defaultRateLimitPolicies = []RateLimitPolicy{
+	{Name: "global", Limit: 600, Period: time.Minute, Scope: ScopeIP},
+	{Name: "auth", Limit: 20, Period: time.Minute, Scope: ScopeIP},
+	{Name: "api", Limit: 300, Period: time.Minute, Scope: ScopeUser},
+	// Every generation is a paid Gemini call and an S3 upload
+	{Name: "generate", Limit: 30, Period: time.Hour, Scope: ScopeUser},
+	{Name: "reveal", Limit: 60, Period: time.Minute, Scope: ScopeUser},
+	// /api/random: requests, and bytes of DRBG output drawn per day
+	{Name: "random", Limit: 120, Period: time.Minute, Scope: ScopeUser},
+	{Name: "random_bytes", Limit: 1 << 20, Period: 24 * time.Hour, Scope: ScopeUser},
+	// Cameras push a frame every few seconds
+	{Name: "device", Limit: 120, Period: time.Minute, Scope: ScopeIP},
+}
+
+// LoadRateLimitConfig reads rate limit and lockout settings from the environment
+func LoadRateLimitConfig() (*RateLimitConfig, error) {
+	cfg := &RateLimitConfig{
+		Enabled:  true,
+		Backend:  strings.ToLower(os.Getenv("RATE_LIMIT_BACKEND")),
+		Policies: make(map[string]RateLimitPolicy),
+		Lockout: LockoutPolicy{
+			Threshold: 5,
+			Window:    15 * time.Minute,
+			BaseLock:  time.Minute,
+			MaxLock:   time.Hour,
+		},
+	}
+
+	var err error
+	if raw := os.Getenv("RATE_LIMIT_ENABLED"); raw != "" {
+		if cfg.Enabled, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("RATE_LIMIT_ENABLED must be true or false")
+		}
+	}
+	switch cfg.Backend {
+	case "":
+		cfg.Backend = "memory"
+	case "memory", "postgres":
+	default:
+		return nil, fmt.Errorf("RATE_LIMIT_BACKEND must be memory or postgres")
+	}
+
+	for _, policy := range defaultRateLimitPolicies {
+		env := "RATE_LIMIT_" + strings.ToUpper(policy.Name)
+		if raw := os.Getenv(env); raw != "" {
+			if policy.Limit, policy.Period, err = parseRate(raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", env, err)
+			}
+		}
+		cfg.Policies[policy.Name] = policy
+	}
+
+	if raw := os.Getenv("LOCKOUT_THRESHOLD"); raw != "" {
+		if cfg.Lockout.Threshold, err = strconv.Atoi(raw); err != nil || cfg.Lockout.Threshold <= 0 {
+			return nil, fmt.Errorf("LOCKOUT_THRESHOLD must be a positive number")
+		}
+	}
+	durations := []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"LOCKOUT_WINDOW", &cfg.Lockout.Window},
+		{"LOCKOUT_BASE", &cfg.Lockout.BaseLock},
+		{"LOCKOUT_MAX", &cfg.Lockout.MaxLock},
+	}
+	for _, d := range durations {
+		if raw := os.Getenv(d.env); raw != "" {
+			if *d.dst, err = time.ParseDuration(raw); err != nil || *d.dst <= 0 {
+				return nil, fmt.Errorf("%s must be a positive duration (e.g. 15m)", d.env)
+			}
+		}
+	}
+	if cfg.Lockout.MaxLock < cfg.Lockout.BaseLock {
+		return nil, fmt.Errorf("LOCKOUT_MAX must not be shorter than LOCKOUT_BASE")
+	}
+	return cfg, nil
+}
+
+// parseRate parses "<limit>/<period>", e.g. "10/1m" or "30/1h"
+func parseRate(raw string) (int, time.Duration, error) {
+	limitStr, periodStr, ok := strings.Cut(raw, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected <limit>/<period>, e.g. 10/1m")
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("limit must be a positive number")
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+	if err != nil || period <= 0 {
+		return 0, 0, fmt.Errorf("period must be a positive duration")
+	}
+	return limit, period, nil
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+)
+
+// minAuthKeyLen is the minimum HMAC key size accepted for session cookies
+const minAuthKeyLen = 32
+
+// weakKeys are placeholder values that must never sign production sessions
+var weakKeys = map[string]bool{
+	"secret":    true,
+	"changeme":  true,
+	"change-me": true,
+	"password":  true,
+	"default":   true,
+}
+
+// SessionConfig holds cookie signing/encryption keys and cookie attributes.
+//
+// Keys are read from SESSION_AUTH_KEYS and SESSION_ENCRYPTION_KEYS as
+// comma-separated, base64-encoded values. The first pair signs new cookies;
+// later pairs are only used to verify cookies issued before a rotation.
+type SessionConfig struct {
+	Production     bool
+	AuthKeys       [][]byte
+	EncryptionKeys [][]byte
+	Secure         bool
+	SameSite       http.SameSite
+	Domain         string
+	MaxAge         int
+	// ReauthWindow is how recent a login or step-up must be to reveal or export secrets
+	ReauthWindow time.Duration
+}
+
+// IsProduction reports whether APP_ENV selects production mode
+func IsProduction() bool {
+	env := strings.ToLower(os.Getenv("APP_ENV"))
+	return env == "production" || env == "prod"
+}
+
+// LoadSessionConfig reads session settings from the environment. In production
+// it returns an error for missing, weak or default keys and insecure cookies;
+// in development it falls back to random per-process keys.
+func LoadSessionConfig() (*SessionConfig, error) {
+	cfg := &SessionConfig{
+		Production:   IsProduction(),
+		Domain:       os.Getenv("COOKIE_DOMAIN"),
+		MaxAge:       86400 * 7,
+		ReauthWindow: 5 * time.Minute,
+	}
+
+	var err error
+	if cfg.AuthKeys, err = parseKeyList(os.Getenv("SESSION_AUTH_KEYS")); err != nil {
+		return nil, fmt.Errorf("SESSION_AUTH_KEYS: %w", err)
+	}
+	if cfg.EncryptionKeys, err = parseKeyList(os.Getenv("SESSION_ENCRYPTION_KEYS")); err != nil {
+		return nil, fmt.Errorf("SESSION_ENCRYPTION_KEYS: %w", err)
+	}
+
+	if raw := os.Getenv("SESSION_MAX_AGE"); raw != "" {
+		if cfg.MaxAge, err = strconv.Atoi(raw); err != nil || cfg.MaxAge <= 0 {
+			return nil, fmt.Errorf("SESSION_MAX_AGE must be a positive number of seconds")
+		}
+	}
+
+	if raw := os.Getenv("REAUTH_WINDOW"); raw != "" {
+		if cfg.ReauthWindow, err = time.ParseDuration(raw); err != nil || cfg.ReauthWindow <= 0 {
+			return nil, fmt.Errorf("REAUTH_WINDOW must be a positive duration (e.g. 5m)")
+		}
+	}
+
+	// Secure defaults to on in production and off for local HTTP development
+	cfg.Secure = cfg.Production
+	if raw := os.Getenv("COOKIE_SECURE"); raw != "" {
+		if cfg.Secure, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("COOKIE_SECURE must be true or false")
+		}
+	}
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "", "lax":
+		cfg.SameSite = http.SameSiteLaxMode
+	case "strict":
+		cfg.SameSite = http.SameSiteStrictMode
+	case "none":
+		cfg.SameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("COOKIE_SAMESITE must be lax, strict or none")
+	}
+
+	if len(cfg.AuthKeys) == 0 && !cfg.Production {
+		log.Println("WARNING: SESSION_AUTH_KEYS not set, using random keys (sessions will not survive a restart)")
+		cfg.AuthKeys = [][]byte{randomKey(64)}
+		cfg.EncryptionKeys = [][]byte{randomKey(32)}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *SessionConfig) validate() error {
+	if len(cfg.AuthKeys) == 0 {
+		return fmt.Errorf("SESSION_AUTH_KEYS is required in production")
+	}
+	if len(cfg.EncryptionKeys) > len(cfg.AuthKeys) {
+		return fmt.Errorf("SESSION_ENCRYPTION_KEYS has more keys than SESSION_AUTH_KEYS")
+	}
+	for i, key := range cfg.AuthKeys {
+		if weakKeys[strings.ToLower(string(key))] {
+			return fmt.Errorf("SESSION_AUTH_KEYS[%d] is a default placeholder value", i)
+		}
+		if cfg.Production && len(key) < minAuthKeyLen {
+			return fmt.Errorf("SESSION_AUTH_KEYS[%d] must be at least %d bytes", i, minAuthKeyLen)
+		}
+	}
+	for i, key := range cfg.EncryptionKeys {
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			return fmt.Errorf("SESSION_ENCRYPTION_KEYS[%d] must be 16, 24 or 32 bytes", i)
+		}
+	}
+	if cfg.SameSite == http.SameSiteNoneMode && !cfg.Secure {
+		return fmt.Errorf("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+
+	if cfg.Production {
+		if len(cfg.EncryptionKeys) < len(cfg.AuthKeys) {
+			return fmt.Errorf("every SESSION_AUTH_KEYS entry needs a SESSION_ENCRYPTION_KEYS entry in production")
+		}
+		if !cfg.Secure {
+			return fmt.Errorf("COOKIE_SECURE cannot be disabled in production")
+		}
+	}
+	return nil
+}
+
+// KeyPairs returns keys in the auth, encryption, auth, encryption... order
+// expected by the gorilla/securecookie based session stores
+func (cfg *SessionConfig) KeyPairs() [][]byte {
+	pairs := make([][]byte, 0, len(cfg.AuthKeys)*2)
+	for i, auth := range cfg.AuthKeys {
+		var enc []byte
+		if i < len(cfg.EncryptionKeys) {
+			enc = cfg.EncryptionKeys[i]
+		}
+		pairs = append(pairs, auth, enc)
+	}
+	return pairs
+}
+
+// Options returns the cookie attributes for the session store
+func (cfg *SessionConfig) Options() sessions.Options {
+	return sessions.Options{
+		Path:     "/",
+		Domain:   cfg.Domain,
+		MaxAge:   cfg.MaxAge,
+		HttpOnly: true,
+		Secure:   cfg.Secure,
+		SameSite: cfg.SameSite,
+	}
+}
+
+// parseKeyList decodes a comma-separated list of base64 keys
+func parseKeyList(raw string) ([][]byte, error) {
+	var keys [][]byte
+	for i, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// Keep known placeholders verbatim so validate can reject them by name
+		if weakKeys[strings.ToLower(part)] {
+			keys = append(keys, []byte(part))
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("key %d is not valid base64", i)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func randomKey(n int) []byte {
+	key := make([]byte, n)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Failed to generate session key: %v", err)
+	}
+	return key
+}
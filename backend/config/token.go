@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// TokenConfig holds settings for bearer access and refresh tokens.
+//
+// TOKEN_SIGNING_KEYS is a comma-separated list of base64 HMAC keys; the
+// first signs new access tokens and the rest are accepted during rotation.
+type TokenConfig struct {
+	SigningKeys [][]byte
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+}
+
+// LoadTokenConfig reads token settings from the environment, refusing weak
+// or missing keys in production
+func LoadTokenConfig() (*TokenConfig, error) {
+	cfg := &TokenConfig{
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+	}
+
+	var err error
+	if cfg.SigningKeys, err = parseKeyList(os.Getenv("TOKEN_SIGNING_KEYS")); err != nil {
+		return nil, fmt.Errorf("TOKEN_SIGNING_KEYS: %w", err)
+	}
+	if raw := os.Getenv("ACCESS_TOKEN_TTL"); raw != "" {
+		if cfg.AccessTTL, err = time.ParseDuration(raw); err != nil || cfg.AccessTTL <= 0 {
+			return nil, fmt.Errorf("ACCESS_TOKEN_TTL must be a positive duration (e.g. 15m)")
+		}
+	}
+	if raw := os.Getenv("REFRESH_TOKEN_TTL"); raw != "" {
+		if cfg.RefreshTTL, err = time.ParseDuration(raw); err != nil || cfg.RefreshTTL <= 0 {
+			return nil, fmt.Errorf("REFRESH_TOKEN_TTL must be a positive duration (e.g. 720h)")
+		}
+	}
+
+	if len(cfg.SigningKeys) == 0 {
+		if IsProduction() {
+			return nil, fmt.Errorf("TOKEN_SIGNING_KEYS is required in production")
+		}
+		log.Println("WARNING: TOKEN_SIGNING_KEYS not set, using a random key (access tokens will not survive a restart)")
+		cfg.SigningKeys = [][]byte{randomKey(32)}
+	}
+	for i, key := range cfg.SigningKeys {
+		if weakKeys[strings.ToLower(string(key))] {
+			return nil, fmt.Errorf("TOKEN_SIGNING_KEYS[%d] is a default placeholder value", i)
+		}
+		if len(key) < minAuthKeyLen {
+			return nil, fmt.Errorf("TOKEN_SIGNING_KEYS[%d] must be at least %d bytes", i, minAuthKeyLen)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WebAuthnConfig holds relying party settings and attestation policy for passkeys
+type WebAuthnConfig struct {
+	RPID        string
+	RPName      string
+	RPOrigins   []string
+	Attestation string // none, indirect, direct or enterprise
+	// AllowedAttestationFormats restricts which attestation statement formats
+	// are accepted at registration (empty = any)
+	AllowedAttestationFormats []string
+	RequireUserVerification   bool
+}
+
+// LoadWebAuthnConfig reads passkey settings. It returns nil (passkeys
+// disabled) when WEBAUTHN_RP_ID is not set.
+func LoadWebAuthnConfig() (*WebAuthnConfig, error) {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		return nil, nil
+	}
+
+	cfg := &WebAuthnConfig{
+		RPID:                      rpID,
+		RPName:                    os.Getenv("WEBAUTHN_RP_NAME"),
+		RPOrigins:                 splitList(os.Getenv("WEBAUTHN_RP_ORIGINS")),
+		Attestation:               strings.ToLower(os.Getenv("WEBAUTHN_ATTESTATION")),
+		AllowedAttestationFormats: splitList(os.Getenv("WEBAUTHN_ALLOWED_ATTESTATION_FORMATS")),
+		RequireUserVerification:   true,
+	}
+	if cfg.RPName == "" {
+		cfg.RPName = "LavaLock"
+	}
+	if len(cfg.RPOrigins) == 0 {
+		cfg.RPOrigins = []string{"https://" + rpID}
+	}
+
+	switch cfg.Attestation {
+	case "":
+		cfg.Attestation = "none"
+	case "none", "indirect", "direct", "enterprise":
+	default:
+		return nil, fmt.Errorf("WEBAUTHN_ATTESTATION must be none, indirect, direct or enterprise")
+	}
+
+	if raw := os.Getenv("WEBAUTHN_REQUIRE_UV"); raw != "" {
+		var err error
+		if cfg.RequireUserVerification, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("WEBAUTHN_REQUIRE_UV must be true or false")
+		}
+	}
+	return cfg, nil
+}
@@ -40,6 +40,105 @@ func InitDB() *gorm.DB {
 	if err := db.AutoMigrate(&models.MFACode{}); err != nil {
 		log.Printf("Failed to migrate MFACode: %v", err)
 	}
+	if err := db.AutoMigrate(&models.Session{}); err != nil {
+		log.Printf("Failed to migrate Session: %v", err)
+	}
+	if err := db.AutoMigrate(&models.RefreshToken{}); err != nil {
+		log.Printf("Failed to migrate RefreshToken: %v", err)
+	}
+	if err := db.AutoMigrate(&models.UserIdentity{}); err != nil {
+		log.Printf("Failed to migrate UserIdentity: %v", err)
+	}
+	if err := db.AutoMigrate(&models.WebAuthnCredential{}, &models.WebAuthnChallenge{}); err != nil {
+		log.Printf("Failed to migrate WebAuthn tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.UserTOTP{}, &models.RecoveryCode{}); err != nil {
+		log.Printf("Failed to migrate two-factor tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.SecretAccess{}); err != nil {
+		log.Printf("Failed to migrate SecretAccess: %v", err)
+	}
+	if err := db.AutoMigrate(&models.AuditEvent{}); err != nil {
+		log.Printf("Failed to migrate AuditEvent: %v", err)
+	}
+	if err := db.AutoMigrate(&models.RateLimitBucket{}, &models.AuthLockout{}); err != nil {
+		log.Printf("Failed to migrate rate limit tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.UserPreferences{}); err != nil {
+		log.Printf("Failed to migrate UserPreferences: %v", err)
+	}
+	if err := db.AutoMigrate(&models.PasswordProvenance{}); err != nil {
+		log.Printf("Failed to migrate PasswordProvenance: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Device{}, &models.Frame{}, &models.DeviceHealth{}); err != nil {
+		log.Printf("Failed to migrate device tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.BeaconPulse{}); err != nil {
+		log.Printf("Failed to migrate BeaconPulse: %v", err)
+	}
+	if err := installRevisionTriggers(db); err != nil {
+		log.Printf("Failed to install revision triggers: %v", err)
+	}
+	if err := installAuditGuard(db); err != nil {
+		log.Printf("Failed to install audit guard: %v", err)
+	}
 
 	return db
 }
+
+// revisionTables are the vault tables whose rows carry a sync revision
+var revisionTables = []string{"password_entries", "vault_groups"}
+
+// installRevisionTriggers makes Postgres stamp every inserted or updated vault row
+// with the next value of a shared sequence, so revisions are monotonic across
+// entries and groups no matter which code path (including soft deletes) wrote them.
+//
+// Sequence values are handed out in call order, not commit order, so the trigger
+// first takes a per-user lock held until commit. Otherwise a pull could see a
+// later revision commit first, advance its cursor past it, and never receive
+// the earlier one.
+func installRevisionTriggers(db *gorm.DB) error {
+	stmts := []string{
+		`CREATE SEQUENCE IF NOT EXISTS vault_revision_seq`,
+		`CREATE OR REPLACE FUNCTION bump_vault_revision() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('vault_revision:' || NEW.user_id::text));
+	NEW.revision := nextval('vault_revision_seq');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`,
+	}
+	for _, table := range revisionTables {
+		stmts = append(stmts,
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS %s_revision ON %s`, table, table),
+			fmt.Sprintf(`CREATE TRIGGER %s_revision BEFORE INSERT OR UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION bump_vault_revision()`, table, table),
+		)
+	}
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installAuditGuard makes audit_events append-only at the database level.
+// The hash chain detects tampering by anyone who bypasses this (e.g. a superuser).
+func installAuditGuard(db *gorm.DB) error {
+	stmts := []string{
+		`CREATE OR REPLACE FUNCTION reject_audit_change() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`,
+		`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events FOR EACH ROW EXECUTE FUNCTION reject_audit_change()`,
+	}
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
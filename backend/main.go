@@ -4,8 +4,11 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/anthonyhana04/Delta-Hacks-2026/backend/api"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
@@ -23,18 +26,116 @@ func main() {
 	r := gin.Default()
 
 	// Session Store
-	store := cookie.NewStore([]byte("secret"))
-	store.Options(sessions.Options{
-		Path:     "/",
-		MaxAge:   86400 * 7,
-		HttpOnly: true,
-		Secure:   false, // Set to true in production with HTTPS
-		SameSite: http.SameSiteLaxMode,
-	})
+	sessionCfg, err := config.LoadSessionConfig()
+	if err != nil {
+		log.Fatalf("Invalid session configuration: %v", err)
+	}
+	store := cookie.NewStore(sessionCfg.KeyPairs()...)
+	store.Options(sessionCfg.Options())
 	r.Use(sessions.Sessions("mysession", store))
 
+	tokenCfg, err := config.LoadTokenConfig()
+	if err != nil {
+		log.Fatalf("Invalid token configuration: %v", err)
+	}
+
+	oidcProviders, err := config.LoadOIDCProviders()
+	if err != nil {
+		log.Fatalf("Invalid identity provider configuration: %v", err)
+	}
+	var providers []*services.OIDCProvider
+	for _, p := range oidcProviders {
+		providers = append(providers, services.NewOIDCProvider(p.Name, p.Issuer, p.ClientIDs, p.ExtraIssuers))
+	}
+
+	passkeyCfg, err := config.LoadWebAuthnConfig()
+	if err != nil {
+		log.Fatalf("Invalid WebAuthn configuration: %v", err)
+	}
+
+	rateLimitCfg, err := config.LoadRateLimitConfig()
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+
+	accountCfg, err := config.LoadAccountConfig()
+	if err != nil {
+		log.Fatalf("Invalid account configuration: %v", err)
+	}
+
+	deviceSeeds, err := config.LoadDeviceSeeds()
+	if err != nil {
+		log.Fatalf("Invalid device configuration: %v", err)
+	}
+
+	deviceCfg, err := config.LoadDeviceConfig()
+	if err != nil {
+		log.Fatalf("Invalid device configuration: %v", err)
+	}
+
+	frameQualityCfg, err := config.LoadFrameQualityConfig()
+	if err != nil {
+		log.Fatalf("Invalid frame quality configuration: %v", err)
+	}
+
+	healthCfg, err := config.LoadEntropyHealthConfig()
+	if err != nil {
+		log.Fatalf("Invalid entropy health configuration: %v", err)
+	}
+
+	mixerCfg, err := config.LoadEntropyMixerConfig()
+	if err != nil {
+		log.Fatalf("Invalid entropy mixer configuration: %v", err)
+	}
+
+	beaconCfg, err := config.LoadBeaconConfig()
+	if err != nil {
+		log.Fatalf("Invalid beacon configuration: %v", err)
+	}
+
 	ctrl := api.NewController()
+	ctrl.SessionTTL = time.Duration(sessionCfg.MaxAge) * time.Second
+	ctrl.ReauthWindow = sessionCfg.ReauthWindow
+	ctrl.DeletionGrace = accountCfg.DeletionGrace
+	ctrl.Tokens = services.NewTokenService(tokenCfg.SigningKeys, tokenCfg.AccessTTL, tokenCfg.RefreshTTL)
+	ctrl.Identity = services.NewOIDCRegistry(providers...)
+	if passkeyCfg != nil {
+		if ctrl.Passkeys, err = api.NewPasskeys(passkeyCfg); err != nil {
+			log.Fatalf("Invalid WebAuthn configuration: %v", err)
+		}
+		ctrl.PasskeyPolicy = passkeyCfg
+	}
+	ctrl.RateLimits = rateLimitCfg
+	if rateLimitCfg.Backend == "postgres" && ctrl.DB != nil {
+		ctrl.Limiter = services.NewPostgresRateLimiter(ctrl.DB)
+		ctrl.Lockouts = services.NewPostgresLockouts(ctrl.DB, rateLimitCfg.Lockout)
+	} else {
+		ctrl.Limiter = services.NewMemoryRateLimiter()
+		ctrl.Lockouts = services.NewMemoryLockouts(rateLimitCfg.Lockout)
+	}
+	ctrl.DeviceConfig = deviceCfg
+	ctrl.FrameQuality = frameQualityCfg
+	if ctrl.Health, err = services.NewEntropyHealth(healthCfg.MinEntropy, healthCfg.StartupFrames); err != nil {
+		log.Fatalf("Entropy health self-test failed: %v", err)
+	}
+	ctrl.Mixer = services.NewEntropyMixer(mixerCfg)
+	if ctrl.DB != nil {
+		if err := ctrl.SeedDevices(deviceSeeds); err != nil {
+			log.Fatalf("Failed to register devices: %v", err)
+		}
+	}
 	ctrl.StartMFAGeneratorLoop()
+	ctrl.StartAccountPurgeLoop()
+	ctrl.StartDeviceMonitor()
+	if beaconCfg.Enabled && ctrl.DB != nil {
+		ctrl.Beacon = services.NewBeacon(ctrl.DB, beaconCfg.SigningKey, beaconCfg.Period, beaconCfg.TrustedKeys...)
+		ctrl.StartBeaconLoop()
+	}
+
+	r.Use(ctrl.RateLimit("global"))
+	authLimit := ctrl.RateLimit("auth")
+	ipLockout := ctrl.AuthLockout(config.ScopeIP)
+	userLockout := ctrl.AuthLockout(config.ScopeUser)
 
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -42,29 +143,137 @@ func main() {
 		})
 	})
 
+	// Public randomness beacon
+	r.GET("/beacon/pulse/last", ctrl.HandleBeaconLast)
+	r.GET("/beacon/pulse/index/:index", ctrl.HandleBeaconByIndex)
+	r.GET("/beacon/pulse/time/:time", ctrl.HandleBeaconByTime)
+	r.GET("/beacon/verify", ctrl.HandleBeaconVerify)
+
+	// Lava lamp cameras push frames with HMAC-signed requests
+	r.POST("/device/enroll", ctrl.RateLimit("device"), ipLockout, ctrl.HandleEnrollDevice)
+	r.POST("/device/heartbeat", ctrl.RateLimit("device"), ipLockout, ctrl.DeviceAuthMiddleware(api.MaxHeartbeatBytes), ctrl.HandleDeviceHeartbeat)
+	r.POST("/device/frames", ctrl.RateLimit("device"), ipLockout, ctrl.DeviceAuthMiddleware(api.MaxFrameBytes), ctrl.HandleIngestFrame)
+
 	// Mobile SDK Login
-	r.POST("/auth/google", ctrl.HandleGoogleLogin)
+	r.POST("/auth/google", authLimit, ipLockout, ctrl.HandleGoogleLogin)
+
+	// Routes reachable while a login still waits for its second factor
+	pending := r.Group("/")
+	pending.Use(ctrl.PendingAuthMiddleware())
+	{
+		pending.POST("/auth/logout", ctrl.HandleLogout)
+		pending.POST("/auth/2fa/verify", userLockout, ctrl.HandleSecondFactorVerify)
+		pending.POST("/api/webauthn/step-up/begin", ctrl.HandlePasskeyStepUpBegin)
+		pending.POST("/api/webauthn/step-up/finish", userLockout, ctrl.HandlePasskeyStepUpFinish)
+	}
+
+	// Bearer Token Login (mobile / CLI)
+	r.POST("/auth/token", authLimit, ipLockout, ctrl.HandleGoogleTokenLogin)
+	r.POST("/auth/token/refresh", authLimit, ipLockout, ctrl.HandleTokenRefresh)
+
+	// Generic OIDC Login (Apple, Microsoft, corporate IdPs)
+	r.GET("/auth/providers", ctrl.HandleListProviders)
+	r.POST("/auth/oidc/:provider", authLimit, ipLockout, ctrl.HandleOIDCLogin)
+	r.POST("/auth/oidc/:provider/token", authLimit, ipLockout, ctrl.HandleOIDCTokenLogin)
+
+	// Passkey (WebAuthn) Login
+	r.POST("/auth/webauthn/login/begin", authLimit, ctrl.HandlePasskeyLoginBegin)
+	r.POST("/auth/webauthn/login/finish", authLimit, ipLockout, ctrl.HandlePasskeyLoginFinish)
+	r.POST("/auth/webauthn/login/finish/token", authLimit, ipLockout, ctrl.HandlePasskeyTokenLoginFinish)
 
 	// Protected Routes
 	authorized := r.Group("/")
-	authorized.Use(ctrl.AuthMiddleware())
+	authorized.Use(ctrl.AuthMiddleware(), ctrl.RateLimit("api"))
 	{
 		// Main interaction endpoint
-		authorized.POST("/api/generate-password", ctrl.HandleGeneratePassword)
+		authorized.POST("/api/generate-password", ctrl.RateLimit("generate"), ctrl.HandleGeneratePassword)
 		authorized.GET("/api/my-passwords", ctrl.HandleListPasswords)
 		authorized.POST("/api/passwords", ctrl.HandleCreatePassword)
 		authorized.DELETE("/api/passwords/:id", ctrl.HandleDeletePassword)
+		authorized.POST("/api/passwords/move", ctrl.HandleMovePasswords)
+		authorized.POST("/api/passwords/bulk", ctrl.HandleBulkPasswords)
+		authorized.POST("/api/passwords/:id/reveal", ctrl.RateLimit("reveal"), ctrl.RequireFreshAuth(), ctrl.HandleRevealPassword)
+		authorized.GET("/api/passwords/:id/provenance", ctrl.RateLimit("reveal"), ctrl.HandleVerifyProvenance)
+		authorized.GET("/api/export", ctrl.RateLimit("reveal"), ctrl.RequireFreshAuth(), ctrl.HandleExportVault)
+		authorized.POST("/api/reauth/:provider", userLockout, ctrl.HandleReauthenticate)
+
+		// Account Export & Deletion
+		authorized.GET("/api/account/export", ctrl.RateLimit("reveal"), ctrl.RequireFreshAuth(), ctrl.HandleAccountExport)
+		authorized.POST("/api/account/deletion", ctrl.RequireFreshAuth(), ctrl.HandleRequestAccountDeletion)
+		authorized.DELETE("/api/account/deletion", ctrl.HandleCancelAccountDeletion)
+
+		// Delta Sync
+		authorized.GET("/api/sync", ctrl.HandleSyncPull)
+		authorized.POST("/api/sync", ctrl.HandleSyncPush)
+
+		// Profile & Preferences
+		authorized.GET("/api/me", ctrl.HandleGetMe)
+		authorized.PATCH("/api/me", ctrl.HandlePatchMe)
+
+		// Session (Device) Endpoints
+		authorized.GET("/api/sessions", ctrl.HandleListSessions)
+		authorized.DELETE("/api/sessions/:id", ctrl.HandleRevokeSession)
+
+		// Linked Identities
+		authorized.GET("/api/identities", ctrl.HandleListIdentities)
+		authorized.POST("/api/identities/:provider", ctrl.HandleLinkIdentity)
+		authorized.DELETE("/api/identities/:id", ctrl.HandleUnlinkIdentity)
+
+		// Passkeys
+		authorized.GET("/api/webauthn/credentials", ctrl.HandleListPasskeys)
+		authorized.DELETE("/api/webauthn/credentials/:id", ctrl.HandleDeletePasskey)
+		authorized.POST("/api/webauthn/register/begin", ctrl.HandlePasskeyRegisterBegin)
+		authorized.POST("/api/webauthn/register/finish", ctrl.HandlePasskeyRegisterFinish)
+
+		// Account Two-Factor (TOTP)
+		authorized.GET("/api/2fa", ctrl.HandleTwoFactorStatus)
+		authorized.POST("/api/2fa/totp/enroll", ctrl.HandleTOTPEnroll)
+		authorized.POST("/api/2fa/totp/confirm", userLockout, ctrl.HandleTOTPConfirm)
+		authorized.POST("/api/2fa/totp/disable", userLockout, ctrl.HandleTOTPDisable)
+		authorized.POST("/api/2fa/recovery-codes", userLockout, ctrl.HandleRegenerateRecoveryCodes)
 
 		// Group Endpoints
 		authorized.GET("/api/groups", ctrl.HandleListGroups)
 		authorized.POST("/api/groups", ctrl.HandleCreateGroup)
 		authorized.DELETE("/api/groups/:id", ctrl.HandleDeleteGroup)
 
+		// Real-time Events
+		authorized.GET("/api/events", ctrl.HandleEventStream)
+		authorized.GET("/api/events/ws", ctrl.HandleEventSocket)
+
+		// Audit Log
+		authorized.GET("/api/audit", ctrl.HandleListAudit)
+
+		// Random values from the entropy pool
+		random := authorized.Group("/api/random", ctrl.RateLimit("random"))
+		random.GET("/bytes", ctrl.HandleRandomBytes)
+		random.GET("/int", ctrl.HandleRandomInt)
+		random.GET("/uuid", ctrl.HandleRandomUUID)
+		random.POST("/shuffle", ctrl.HandleRandomShuffle)
+		random.POST("/choice", ctrl.HandleRandomChoice)
+
  // MFA Endpoints
  authorized.GET("/api/mfa/generate", ctrl.HandleGenerateMFACode)
 
 	}
 
+	// Admin Routes
+	admin := r.Group("/api/admin")
+	admin.Use(ctrl.AuthMiddleware(), ctrl.AdminMiddleware())
+	{
+		admin.GET("/audit", ctrl.HandleAdminListAudit)
+		admin.GET("/audit/verify", ctrl.HandleAdminVerifyAudit)
+
+		admin.GET("/devices", ctrl.HandleAdminListDevices)
+		admin.GET("/devices/health", ctrl.HandleAdminFleetHealth)
+		admin.GET("/entropy/health", ctrl.HandleAdminEntropyHealth)
+		admin.GET("/entropy/sources", ctrl.HandleAdminEntropySources)
+		admin.POST("/devices", ctrl.HandleAdminProvisionDevice)
+		admin.POST("/devices/:id/rotate", ctrl.HandleAdminRotateDeviceKey)
+		admin.POST("/devices/:id/disable", ctrl.HandleAdminDisableDevice)
+		admin.GET("/devices/:id/frames", ctrl.HandleAdminListDeviceFrames)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit results
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent is one row of the append-only audit log. Each row's Hash covers
+// its own fields and the previous row's hash, so editing or deleting a row
+// breaks the chain from that point on (see services.AuditLog.Verify).
+type AuditEvent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Seq       int64     `gorm:"uniqueIndex;not null" json:"seq"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"` // nil for anonymous (e.g. failed login)
+	SessionID  *uuid.UUID `gorm:"type:uuid" json:"session_id"`
+	Action     string     `gorm:"index;not null" json:"action"`
+	TargetType string     `json:"target_type"`
+	TargetID   string     `gorm:"index" json:"target_id"`
+	Result     string     `gorm:"not null" json:"result"`
+	Detail     string     `json:"detail"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+
+	PrevHash string `gorm:"not null" json:"prev_hash"`
+	Hash     string `gorm:"not null" json:"hash"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Beacon pulse status flags (bitmask)
+const (
+	// PulseChainStart marks the first pulse, or the first after a gap
+	PulseChainStart = 1 << iota
+	// PulseNoFrames means no fresh frames were committed; the output rests on
+	// the server's local random value alone
+	PulseNoFrames
+	// PulseHealthAlarm means the entropy health tests were not passing
+	PulseHealthAlarm
+)
+
+// FrameCommitment commits a pulse to a frame without revealing it
+type FrameCommitment struct {
+	DeviceID   uuid.UUID `json:"device_id"`
+	SHA256     string    `json:"sha256"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// FrameCommitments is stored as a JSON document
+type FrameCommitments []FrameCommitment
+
+func (f FrameCommitments) Value() (driver.Value, error) {
+	if f == nil {
+		f = FrameCommitments{}
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (f *FrameCommitments) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case []byte:
+		return json.Unmarshal(v, f)
+	default:
+		return fmt.Errorf("cannot scan %T into FrameCommitments", value)
+	}
+}
+
+// BeaconPulse is one signed, hash-chained beacon output. OutputValue is the
+// SHA-512 of the signed fields and the signature; PreviousOutput links it to
+// the pulse before.
+type BeaconPulse struct {
+	Index     int64     `gorm:"column:pulse_index;primaryKey;autoIncrement:false" json:"index"`
+	Version   string    `gorm:"not null" json:"version"`
+	TimeStamp time.Time `gorm:"not null;uniqueIndex" json:"timestamp"`
+	PeriodMs  int64     `gorm:"not null" json:"period_ms"`
+	Status    int       `gorm:"not null" json:"status"`
+
+	LocalRandom    string           `gorm:"not null" json:"local_random"` // hex, 64 bytes from the OS CSPRNG
+	Frames         FrameCommitments `gorm:"type:text;not null" json:"frames"`
+	PreviousOutput string           `gorm:"not null" json:"previous_output"`
+
+	PublicKey   string `gorm:"not null" json:"public_key"` // hex Ed25519 key
+	Signature   string `gorm:"not null" json:"signature"`  // hex
+	OutputValue string `gorm:"not null" json:"output_value"`
+}
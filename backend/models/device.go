@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Device statuses. A provisioned device stays pending until it enrolls.
+const (
+	DeviceStatusPending  = "pending"
+	DeviceStatusActive   = "active"
+	DeviceStatusDisabled = "disabled"
+)
+
+// Device is a lava lamp camera allowed to upload frames
+type Device struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name            string     `gorm:"uniqueIndex;not null" json:"name"`
+	Location        string     `json:"location"`
+	Status          string     `gorm:"not null;default:'active'" json:"status"`
+	FirmwareVersion string     `json:"firmware_version"`
+	Key             []byte     `json:"-"` // HMAC-SHA256 request signing key, nil until enrolled
+	KeyRotatedAt    *time.Time `json:"key_rotated_at"`
+	LastSeenAt      *time.Time `json:"last_seen_at"`
+
+	// One-time enrollment token (SHA-256 hash); cleared once used
+	EnrollmentHash      string     `json:"-"`
+	EnrollmentExpiresAt *time.Time `json:"enrollment_expires_at,omitempty"`
+}
+
+func (base *Device) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
+
+// Frame statuses. Rejected frames are recorded but never stored or used.
+const (
+	FrameAccepted = "accepted"
+	FrameRejected = "rejected"
+)
+
+// Frame reject reasons
+const (
+	RejectUndecodable = "undecodable"
+	RejectTooDark     = "too_dark"
+	RejectTooBright   = "too_bright"
+	RejectLowContrast = "low_contrast"
+	RejectFrozen      = "frozen"    // barely differs from the previous frame
+	RejectSimilar     = "similar"   // perceptual hash matches a recent frame
+	RejectDuplicate   = "duplicate" // exact bytes already seen from another device
+	RejectHealthTest  = "health_test"
+)
+
+// Frame is one lava lamp image received from a device
+type Frame struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"` // when the backend received it
+
+	DeviceID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_frame_device_hash,priority:1;index:idx_frame_device_captured,priority:1" json:"device_id"`
+	CapturedAt time.Time `gorm:"not null;index:idx_frame_device_captured,priority:2" json:"captured_at"`
+	S3Key      string    `json:"s3_key,omitempty"` // empty for rejected frames
+	Size       int64     `gorm:"not null" json:"size"`
+	SHA256     string    `gorm:"not null;uniqueIndex:idx_frame_device_hash,priority:2;index" json:"sha256"`
+
+	Status       string   `gorm:"not null;default:'accepted';index" json:"status"`
+	RejectReason string   `json:"reject_reason,omitempty"`
+	Brightness   float64  `json:"brightness"`
+	Contrast     float64  `json:"contrast"`
+	Difference   *float64 `json:"difference"` // vs. the device's previous frame
+	DHash        int64    `json:"dhash"`      // uint64 difference hash, bit-cast
+	Thumb        []byte   `json:"-"`          // 16x16 grayscale thumbnail
+}
+
+func (base *Frame) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
+
+// Device health states
+const (
+	DeviceHealthy  = "healthy"
+	DeviceDegraded = "degraded"
+	DeviceStale    = "stale"
+)
+
+// Capture results a camera reports in its heartbeat
+const (
+	CaptureOK      = "ok"
+	CaptureFailed  = "failed"
+	CapturePending = "pending"
+)
+
+// DeviceHealth is the latest heartbeat from a device
+type DeviceHealth struct {
+	DeviceID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"device_id"`
+	ReceivedAt time.Time `gorm:"not null;index" json:"received_at"`
+	State      string    `gorm:"not null" json:"state"`
+
+	UptimeSeconds    int64  `json:"uptime_seconds"`
+	RSSI             int    `json:"rssi"` // Wi-Fi signal, dBm
+	FreeHeap         int64  `json:"free_heap"`
+	LastCapture      string `json:"last_capture"`
+	LastCaptureError string `json:"last_capture_error,omitempty"`
+	ClockSkewMs      int64  `json:"clock_skew_ms"` // device clock minus server clock
+}
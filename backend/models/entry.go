@@ -13,17 +13,23 @@ type PasswordEntry struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Revision is assigned by a database trigger on every insert/update
+	// (see database.installRevisionTriggers) and drives delta sync.
+	Revision int64 `gorm:"index;not null;default:0" json:"revision"`
+
 	UserID         *uuid.UUID `json:"user_id"`
 	GroupID        *uuid.UUID `json:"group_id"`
 	S3Key          string     `json:"s3_key"`
 	WallpaperS3Key string     `json:"wallpaper_s3_key"`
-	Password       string     `json:"password"`
+	Password       string     `json:"-"` // Only served by the reveal/export endpoints
 	EntropyScore   int        `json:"entropy_score"`
 
 	// Metadata
-	Name       string `json:"name"`
-	Username   string `json:"username"`
-	WebsiteURL string `json:"website_url"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	WebsiteURL string     `json:"website_url"`
+	Favorite   bool       `gorm:"not null;default:false" json:"favorite"`
+	Tags       StringList `gorm:"type:text;not null;default:'[]'" json:"tags"`
 }
 
 func (base *PasswordEntry) BeforeCreate(tx *gorm.DB) (err error) {
@@ -13,6 +13,10 @@ type VaultGroup struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Revision is assigned by a database trigger on every insert/update
+	// (see database.installRevisionTriggers) and drives delta sync.
+	Revision int64 `gorm:"index;not null;default:0" json:"revision"`
+
 	UserID uuid.UUID `json:"user_id"`
 	Name   string    `json:"name"`
 	Icon   string    `json:"icon"`
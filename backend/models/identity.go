@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links an external identity provider account to a user.
+// A user may have several identities (Google, Apple, a corporate IdP...).
+type UserIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID   uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	Provider string    `gorm:"uniqueIndex:idx_identity_provider_subject;not null" json:"provider"`
+	Subject  string    `gorm:"uniqueIndex:idx_identity_provider_subject;not null" json:"-"`
+	Email    string    `json:"email"`
+}
+
+func (base *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
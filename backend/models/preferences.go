@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Generator length bounds (see HandleGeneratePassword)
+const (
+	MinGeneratorLength     = 8
+	MaxGeneratorLength     = 32
+	DefaultGeneratorLength = 20
+)
+
+// Preferences is the settings document synced across a user's devices
+type Preferences struct {
+	Generator      GeneratorPreferences    `json:"generator"`
+	DefaultGroupID *uuid.UUID              `json:"default_group_id"`
+	MFA            MFAPreferences          `json:"mfa"`
+	Notifications  NotificationPreferences `json:"notifications"`
+	Security       SecurityPreferences     `json:"security"`
+}
+
+type GeneratorPreferences struct {
+	Length int `json:"length"`
+}
+
+type MFAPreferences struct {
+	ShowTimer bool `json:"show_timer"`
+	MaskCodes bool `json:"mask_codes"` // hide codes until tapped
+}
+
+type NotificationPreferences struct {
+	Enabled        bool `json:"enabled"`
+	NewDeviceLogin bool `json:"new_device_login"`
+	VaultChanges   bool `json:"vault_changes"`
+	SecurityAlerts bool `json:"security_alerts"`
+}
+
+type SecurityPreferences struct {
+	BiometricUnlock bool `json:"biometric_unlock"` // enforced on the device
+}
+
+// DefaultPreferences matches the app's out-of-the-box settings
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Generator: GeneratorPreferences{Length: DefaultGeneratorLength},
+		MFA:       MFAPreferences{ShowTimer: true},
+		Notifications: NotificationPreferences{
+			NewDeviceLogin: true,
+			SecurityAlerts: true,
+		},
+	}
+}
+
+func (p Preferences) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan starts from the defaults so settings added later get sensible values
+func (p *Preferences) Scan(value interface{}) error {
+	*p = DefaultPreferences()
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	default:
+		return fmt.Errorf("cannot scan %T into Preferences", value)
+	}
+}
+
+// UserPreferences stores one Preferences document per user. Version is
+// bumped on every change so clients can detect concurrent edits.
+type UserPreferences struct {
+	UserID    uuid.UUID   `gorm:"type:uuid;primaryKey" json:"-"`
+	Data      Preferences `gorm:"type:text;not null" json:"preferences"`
+	Version   int64       `gorm:"not null;default:0" json:"version"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
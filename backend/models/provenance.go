@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordProvenance records what a generated password was derived from.
+// It is created at generation time and attached to the entry when the
+// password is saved. The nonce never leaves the server, so the hashes and
+// commitments alone aren't enough to recompute the password.
+type PasswordProvenance struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"` // generation time
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"-"`
+	EntryID   *uuid.UUID `gorm:"type:uuid;uniqueIndex" json:"entry_id"`
+
+	FrameS3Key      string `json:"frame_s3_key"`
+	FrameSHA256     string `json:"frame_sha256"`
+	WallpaperS3Key  string `json:"wallpaper_s3_key"`
+	WallpaperSHA256 string `json:"wallpaper_sha256"`
+	FleetDigest     string `json:"fleet_digest,omitempty"` // hex; empty when no camera frames were pooled
+	Length          int    `json:"length"`
+
+	Nonce            []byte `gorm:"not null" json:"-"`
+	NonceCommitment  string `gorm:"not null" json:"nonce_commitment"`
+	OutputCommitment string `gorm:"not null" json:"-"`
+}
+
+func (p *PasswordProvenance) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
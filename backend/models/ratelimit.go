@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the shared state of one rate limit key (GCRA: only the
+// theoretical arrival time of the next request needs to be stored)
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey"`
+	TAT       time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// AuthLockout tracks failed authentication attempts for one key
+type AuthLockout struct {
+	Key           string    `gorm:"primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	Level         int       `gorm:"not null;default:0"` // number of lockouts so far, drives the backoff
+	WindowStart   time.Time `gorm:"not null"`
+	LockedUntil   time.Time
+	LastFailureAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a single-use token in a rotation chain. Every token issued
+// for one login shares SessionID; presenting an already-used token is treated
+// as theft and revokes the whole session.
+type RefreshToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	SessionID uuid.UUID  `gorm:"type:uuid;index;not null" json:"session_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (base *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
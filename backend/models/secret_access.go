@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecretAccess records each time plaintext secrets left the server
+type SecretAccess struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	SessionID uuid.UUID  `gorm:"type:uuid" json:"session_id"`
+	EntryID   *uuid.UUID `gorm:"type:uuid;index" json:"entry_id"` // nil for a full export
+	Action    string     `gorm:"not null" json:"action"`          // "reveal" or "export"
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+}
+
+func (base *SecretAccess) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
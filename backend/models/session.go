@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is a server-side login session for one device. The cookie only
+// carries a random token; TokenHash is its SHA-256 so a DB leak can't be replayed.
+type Session struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID     uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	DeviceName string     `json:"device_name"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// StepUpAt is the last time the user re-verified with a second factor on this session
+	StepUpAt *time.Time `json:"step_up_at,omitempty"`
+	// SecondFactorPending is set at login for users with two-factor enabled and
+	// cleared once they verify; pending sessions can't reach protected routes
+	SecondFactorPending bool `gorm:"not null;default:false" json:"second_factor_pending"`
+}
+
+func (base *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
+
+// Active reports whether the session can still authenticate requests
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserTOTP is a user's authenticator-app secret. Until ConfirmedAt is set
+// the enrollment is pending and login is not gated on it.
+type UserTOTP struct {
+	UserID      uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Secret      []byte     `gorm:"not null" json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the last accepted TOTP time step, to block code replay
+	LastUsedStep int64 `json:"-"`
+}
+
+// RecoveryCode is a single-use backup code, stored as a SHA-256 hash
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func (base *RecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
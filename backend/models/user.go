@@ -7,12 +7,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+)
+
 type User struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	GoogleID  string    `json:"google_id"`
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
+	Role      string    `gorm:"not null;default:'member'" json:"role"` // "member" or "admin"
 	CreatedAt time.Time `json:"created_at"`
+
+	// DeleteAfter is set while a deletion request is pending; the account is
+	// purged once it passes unless the request is cancelled
+	DeleteAfter *time.Time `gorm:"index" json:"delete_after"`
 }
 
 func (base *User) BeforeCreate(tx *gorm.DB) (err error) {
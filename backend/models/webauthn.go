@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential is a registered passkey or security key
+type WebAuthnCredential struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID          uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	Name            string     `json:"name"`
+	CredentialID    []byte     `gorm:"uniqueIndex;not null" json:"-"`
+	PublicKey       []byte     `gorm:"not null" json:"-"`
+	AttestationType string     `json:"attestation_type"`
+	AAGUID          []byte     `json:"-"`
+	Transports      StringList `gorm:"type:text;not null;default:'[]'" json:"transports"`
+	Flags           uint8      `json:"-"` // Raw authenticator flags (UP/UV/BE/BS)
+	SignCount       int64      `json:"sign_count"`
+	CloneWarning    bool       `json:"clone_warning"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+}
+
+func (base *WebAuthnCredential) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
+
+// WebAuthnChallenge holds server-side ceremony state between begin and finish.
+// Data is the library's serialized session data.
+type WebAuthnChallenge struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	SessionID *uuid.UUID `gorm:"type:uuid" json:"session_id"`
+	Purpose   string     `gorm:"not null" json:"purpose"`
+	Data      string     `gorm:"type:text;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`
+}
+
+func (base *WebAuthnChallenge) BeforeCreate(tx *gorm.DB) (err error) {
+	if base.ID == uuid.Nil {
+		base.ID = uuid.New()
+	}
+	return
+}
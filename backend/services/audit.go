@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditLockKey serialises appends across instances (pg_advisory_xact_lock)
+const auditLockKey = 0x6c61766161756474 // "lavaaudt"
+
+// auditGenesisHash is the PrevHash of the first event
+var auditGenesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// AuditLog appends hash-chained events to the audit_events table
+type AuditLog struct {
+	db *gorm.DB
+}
+
+func NewAuditLog(db *gorm.DB) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+// AuditHash is the chain hash of e given the previous event's hash.
+// Only fields set before insert are covered, in a fixed order.
+func AuditHash(prevHash string, e *models.AuditEvent) string {
+	fields := []interface{}{
+		e.ID.String(),
+		e.Seq,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		uuidString(e.ActorID),
+		uuidString(e.SessionID),
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Result,
+		e.Detail,
+		e.IPAddress,
+		e.UserAgent,
+	}
+	payload, _ := json.Marshal(fields)
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// Append assigns the next sequence number, links the event to the chain and
+// stores it. Appends are serialised with a transaction-scoped advisory lock.
+func (a *AuditLog) Append(e *models.AuditEvent) error {
+	if a == nil || a.db == nil {
+		return nil
+	}
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLockKey).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditEvent
+		prevHash, seq := auditGenesisHash, int64(1)
+		err := tx.Order("seq desc").Limit(1).Find(&last).Error
+		if err != nil {
+			return err
+		}
+		if last.Seq > 0 {
+			prevHash, seq = last.Hash, last.Seq+1
+		}
+
+		if e.ID == uuid.Nil {
+			e.ID = uuid.New()
+		}
+		// Postgres keeps microseconds; truncate so the stored value hashes the same
+		e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		e.Seq = seq
+		e.PrevHash = prevHash
+		e.Hash = AuditHash(prevHash, e)
+		return tx.Create(e).Error
+	})
+}
+
+// AuditVerification is the outcome of walking the chain
+type AuditVerification struct {
+	Checked  int64  `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt int64  `json:"broken_at,omitempty"` // seq of the first bad event
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Verify recomputes every hash in sequence order and reports the first event
+// whose link or content doesn't match
+func (a *AuditLog) Verify(ctx context.Context) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	prevHash, expectSeq := auditGenesisHash, int64(1)
+
+	const batch = 1000
+	for {
+		var events []models.AuditEvent
+		if err := a.db.WithContext(ctx).Where("seq >= ?", expectSeq).Order("seq asc").Limit(batch).Find(&events).Error; err != nil {
+			return nil, err
+		}
+		for i := range events {
+			e := &events[i]
+			result.Checked++
+			switch {
+			case e.Seq != expectSeq:
+				result.Valid, result.BrokenAt, result.Reason = false, expectSeq, "missing event"
+			case e.PrevHash != prevHash:
+				result.Valid, result.BrokenAt, result.Reason = false, e.Seq, "previous hash mismatch"
+			case AuditHash(prevHash, e) != e.Hash:
+				result.Valid, result.BrokenAt, result.Reason = false, e.Seq, "content hash mismatch"
+			}
+			if !result.Valid {
+				return result, nil
+			}
+			prevHash, expectSeq = e.Hash, e.Seq+1
+		}
+		if len(events) < batch {
+			return result, nil
+		}
+	}
+}
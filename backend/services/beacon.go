@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"gorm.io/gorm"
+)
+
+// BeaconVersion identifies the pulse format covered by the signature
+const BeaconVersion = "lavalock-beacon/1"
+
+const (
+	// beaconLockKey serialises pulses across instances (pg_advisory_xact_lock)
+	beaconLockKey = 0x6c617661626e636e // "lavabncn"
+	// maxPulseFrames caps how many frame commitments one pulse carries
+	maxPulseFrames = 64
+)
+
+// beaconGenesisOutput is the PreviousOutput of the first pulse
+var beaconGenesisOutput = hex.EncodeToString(make([]byte, sha512.Size))
+
+// Beacon publishes signed, hash-chained randomness pulses in the style of
+// the NIST Randomness Beacon 2.0
+type Beacon struct {
+	db      *gorm.DB
+	key     ed25519.PrivateKey
+	period  time.Duration
+	trusted map[string]bool // hex public keys pulses may be signed with
+}
+
+// NewBeacon signs with key; pulses signed by key or any of trusted verify
+func NewBeacon(db *gorm.DB, key ed25519.PrivateKey, period time.Duration, trusted ...ed25519.PublicKey) *Beacon {
+	b := &Beacon{db: db, key: key, period: period, trusted: make(map[string]bool)}
+	b.trusted[b.PublicKey()] = true
+	for _, pub := range trusted {
+		b.trusted[hex.EncodeToString(pub)] = true
+	}
+	return b
+}
+
+// Period is the time between pulses
+func (b *Beacon) Period() time.Duration {
+	return b.period
+}
+
+// PublicKey is the hex Ed25519 key pulses are signed with
+func (b *Beacon) PublicKey() string {
+	return hex.EncodeToString(b.key.Public().(ed25519.PublicKey))
+}
+
+// PulseMessage is the canonical encoding of the fields a pulse's signature
+// covers, in a fixed order
+func PulseMessage(p *models.BeaconPulse) []byte {
+	frames := make([][]string, len(p.Frames))
+	for i, f := range p.Frames {
+		frames[i] = []string{f.DeviceID.String(), f.SHA256, f.CapturedAt.UTC().Format(time.RFC3339Nano)}
+	}
+	fields := []interface{}{
+		p.Version,
+		p.Index,
+		p.TimeStamp.UTC().Format(time.RFC3339Nano),
+		p.PeriodMs,
+		p.Status,
+		p.LocalRandom,
+		frames,
+		p.PreviousOutput,
+		p.PublicKey,
+	}
+	payload, _ := json.Marshal(fields)
+	return payload
+}
+
+// PulseOutput is the pulse's output value: SHA-512 over the signed message
+// and the signature
+func PulseOutput(message []byte, signature string) string {
+	sig, _ := hex.DecodeString(signature)
+	h := sha512.New()
+	h.Write(message)
+	h.Write(sig)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Emit publishes the pulse for the period containing now, committing to the
+// frames accepted since the previous pulse. It returns nil if another
+// instance already published this period's pulse. healthy is false while the
+// entropy health tests are failing; the pulse is still published, flagged.
+func (b *Beacon) Emit(ctx context.Context, healthy bool) (*models.BeaconPulse, error) {
+	var pulse *models.BeaconPulse
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", beaconLockKey).Error; err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		stamp := now.Truncate(b.period)
+		var last models.BeaconPulse
+		if err := tx.Order("pulse_index desc").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		if last.Index > 0 && !last.TimeStamp.Before(stamp) {
+			return nil
+		}
+
+		p := &models.BeaconPulse{
+			Index:          last.Index + 1,
+			Version:        BeaconVersion,
+			TimeStamp:      stamp,
+			PeriodMs:       b.period.Milliseconds(),
+			PreviousOutput: beaconGenesisOutput,
+			PublicKey:      b.PublicKey(),
+		}
+		since := stamp.Add(-b.period)
+		if last.Index > 0 {
+			p.PreviousOutput = last.OutputValue
+			since = last.TimeStamp
+		}
+		if last.Index == 0 || !last.TimeStamp.Equal(stamp.Add(-b.period)) {
+			p.Status |= models.PulseChainStart
+		}
+		if !healthy {
+			p.Status |= models.PulseHealthAlarm
+		}
+
+		var frames []models.Frame
+		if err := tx.Select("device_id", "sha256", "captured_at").
+			Where("status = ? AND created_at > ? AND created_at <= ?", models.FrameAccepted, since, now).
+			Order("created_at desc").Limit(maxPulseFrames).Find(&frames).Error; err != nil {
+			return err
+		}
+		for _, f := range frames {
+			p.Frames = append(p.Frames, models.FrameCommitment{
+				DeviceID:   f.DeviceID,
+				SHA256:     f.SHA256,
+				CapturedAt: f.CapturedAt.UTC(),
+			})
+		}
+		sort.Slice(p.Frames, func(i, j int) bool {
+			a, b := p.Frames[i], p.Frames[j]
+			if c := bytes.Compare(a.DeviceID[:], b.DeviceID[:]); c != 0 {
+				return c < 0
+			}
+			return a.SHA256 < b.SHA256
+		})
+		if len(p.Frames) == 0 {
+			p.Status |= models.PulseNoFrames
+		}
+
+		local := make([]byte, 64)
+		if _, err := rand.Read(local); err != nil {
+			return err
+		}
+		p.LocalRandom = hex.EncodeToString(local)
+
+		message := PulseMessage(p)
+		p.Signature = hex.EncodeToString(ed25519.Sign(b.key, message))
+		p.OutputValue = PulseOutput(message, p.Signature)
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		pulse = p
+		return nil
+	})
+	return pulse, err
+}
+
+// VerifyPulse checks that a pulse is signed by a trusted key, its signature
+// and output value, and its link to prev (nil for the first pulse). The key
+// carried in the pulse is only trusted if the beacon was configured with it,
+// otherwise anyone able to write the table could re-sign the chain.
+func (b *Beacon) VerifyPulse(p, prev *models.BeaconPulse) error {
+	if !b.trusted[p.PublicKey] {
+		return fmt.Errorf("untrusted public key")
+	}
+	key, err := hex.DecodeString(p.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key")
+	}
+	sig, err := hex.DecodeString(p.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding")
+	}
+	message := PulseMessage(p)
+	if !ed25519.Verify(key, message, sig) {
+		return fmt.Errorf("signature mismatch")
+	}
+	if PulseOutput(message, p.Signature) != p.OutputValue {
+		return fmt.Errorf("output value mismatch")
+	}
+
+	expected := beaconGenesisOutput
+	if prev != nil {
+		expected = prev.OutputValue
+		if !p.TimeStamp.After(prev.TimeStamp) {
+			return fmt.Errorf("timestamp not after previous pulse")
+		}
+	}
+	if p.PreviousOutput != expected {
+		return fmt.Errorf("previous output mismatch")
+	}
+	return nil
+}
+
+// BeaconVerification is the outcome of walking the pulse chain
+type BeaconVerification struct {
+	From     int64  `json:"from"`
+	Checked  int64  `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt int64  `json:"broken_at,omitempty"` // index of the first bad pulse
+	Reason   string `json:"reason,omitempty"`
+}
+
+// VerifyChain checks pulses from..to (inclusive; to <= 0 means the latest),
+// anchoring on the pulse before from
+func (b *Beacon) VerifyChain(ctx context.Context, from, to int64) (*BeaconVerification, error) {
+	from = max(from, 1)
+	result := &BeaconVerification{From: from, Valid: true}
+	db := b.db.WithContext(ctx)
+
+	var prev *models.BeaconPulse
+	if from > 1 {
+		var anchor models.BeaconPulse
+		if err := db.Where("pulse_index = ?", from-1).Limit(1).Find(&anchor).Error; err != nil {
+			return nil, err
+		}
+		if anchor.Index == 0 {
+			result.Valid, result.BrokenAt, result.Reason = false, from-1, "missing pulse"
+			return result, nil
+		}
+		prev = &anchor
+	}
+
+	const batch = 1000
+	expect := from
+	for {
+		query := db.Where("pulse_index >= ?", expect).Order("pulse_index asc").Limit(batch)
+		if to > 0 {
+			query = query.Where("pulse_index <= ?", to)
+		}
+		var pulses []models.BeaconPulse
+		if err := query.Find(&pulses).Error; err != nil {
+			return nil, err
+		}
+		for i := range pulses {
+			p := &pulses[i]
+			result.Checked++
+			if p.Index != expect {
+				result.Valid, result.BrokenAt, result.Reason = false, expect, "missing pulse"
+				return result, nil
+			}
+			if err := b.VerifyPulse(p, prev); err != nil {
+				result.Valid, result.BrokenAt, result.Reason = false, p.Index, err.Error()
+				return result, nil
+			}
+			prev, expect = p, p.Index+1
+		}
+		if len(pulses) < batch {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"sync"
+)
+
+const (
+	// drbgMaxRequest is the most one generate call may return (SP 800-90A
+	// caps HMAC_DRBG requests at 2^19 bits)
+	drbgMaxRequest = 1 << 16
+	// drbgReseedInterval is how many generate calls are allowed between
+	// reseeds; far below the 2^48 the standard permits
+	drbgReseedInterval = 1 << 16
+)
+
+// ErrDRBGUnseeded is returned until the DRBG has been seeded from a frame
+var ErrDRBGUnseeded = errors.New("random generator has not been seeded")
+
+// DRBG is an HMAC_DRBG (NIST SP 800-90A) with SHA-256, seeded from lava
+// frames and reseeded whenever a new frame arrives. Every seed is mixed with
+// OS randomness, so output never rests on the camera alone.
+type DRBG struct {
+	mu      sync.Mutex
+	k, v    []byte
+	counter uint64
+	source  string // key of the frame last seeded from
+	seeded  bool
+}
+
+func NewDRBG() *DRBG {
+	return &DRBG{}
+}
+
+// update is the HMAC_DRBG update function
+func (d *DRBG) update(provided ...[]byte) {
+	mac := func(key []byte, parts ...[]byte) []byte {
+		h := hmac.New(sha256.New, key)
+		for _, p := range parts {
+			h.Write(p)
+		}
+		return h.Sum(nil)
+	}
+	data := append([][]byte{d.v, {0x00}}, provided...)
+	d.k = mac(d.k, data...)
+	d.v = mac(d.k, d.v)
+	n := 0
+	for _, p := range provided {
+		n += len(p)
+	}
+	if n == 0 {
+		return
+	}
+	data = append([][]byte{d.v, {0x01}}, provided...)
+	d.k = mac(d.k, data...)
+	d.v = mac(d.k, d.v)
+}
+
+// Seed (re)seeds the generator from a frame. The first call instantiates it;
+// later calls reseed, keeping the existing state in the mix.
+func (d *DRBG) Seed(source string, entropy []byte) error {
+	osEntropy := make([]byte, 48)
+	if _, err := rand.Read(osEntropy); err != nil {
+		return err
+	}
+	frameHash := sha256.Sum256(entropy)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// 32 bytes of entropy input plus a 16 byte nonce from the OS, and the
+	// frame hash as personalization / additional input
+	additional := append(frameHash[:], source...)
+	if !d.seeded {
+		d.instantiate(osEntropy[:32], osEntropy[32:], additional)
+	} else {
+		d.reseed(osEntropy, additional)
+	}
+	d.source = source
+	return nil
+}
+
+// instantiate is HMAC_DRBG_Instantiate_algorithm
+func (d *DRBG) instantiate(entropy, nonce, personalization []byte) {
+	d.k = make([]byte, sha256.Size)
+	d.v = make([]byte, sha256.Size)
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(entropy, nonce, personalization)
+	d.counter = 1
+	d.seeded = true
+}
+
+// reseed is HMAC_DRBG_Reseed_algorithm
+func (d *DRBG) reseed(entropy, additional []byte) {
+	d.update(entropy, additional)
+	d.counter = 1
+}
+
+// NeedsReseed reports whether the generator should be reseeded before use:
+// it is unseeded, the reseed interval has run out, or source is a frame it
+// hasn't seen yet
+func (d *DRBG) NeedsReseed(source string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.seeded || d.counter > drbgReseedInterval || source != d.source
+}
+
+// Usable reports whether the generator may keep serving from its current
+// seed: it has been seeded and the reseed interval hasn't run out
+func (d *DRBG) Usable() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.seeded && d.counter <= drbgReseedInterval
+}
+
+// Read fills p with output, splitting large reads into several generate calls
+func (d *DRBG) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.seeded {
+		return 0, ErrDRBGUnseeded
+	}
+	for off := 0; off < len(p); off += drbgMaxRequest {
+		d.generate(p[off:min(off+drbgMaxRequest, len(p))])
+	}
+	return len(p), nil
+}
+
+func (d *DRBG) generate(out []byte) {
+	for off := 0; off < len(out); {
+		h := hmac.New(sha256.New, d.k)
+		h.Write(d.v)
+		d.v = h.Sum(nil)
+		off += copy(out[off:], d.v)
+	}
+	d.update()
+	d.counter++
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PooledFrame is a frame held in memory for the generators
+type PooledFrame struct {
+	DeviceID   uuid.UUID
+	Key        string
+	Data       []byte
+	CapturedAt time.Time
+}
+
+// EntropyPool holds the newest frame from each device so the generators
+// don't have to list and download from S3 on every request
+type EntropyPool struct {
+	mu     sync.RWMutex
+	latest map[uuid.UUID]*PooledFrame
+}
+
+func NewEntropyPool() *EntropyPool {
+	return &EntropyPool{latest: make(map[uuid.UUID]*PooledFrame)}
+}
+
+// AddFrame makes a freshly ingested frame available immediately
+func (p *EntropyPool) AddFrame(frame *PooledFrame) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cur, ok := p.latest[frame.DeviceID]; ok && cur.CapturedAt.After(frame.CapturedAt) {
+		return
+	}
+	p.latest[frame.DeviceID] = frame
+}
+
+// Latest returns the newest frame from any device captured within maxAge
+func (p *EntropyPool) Latest(maxAge time.Duration) (*PooledFrame, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var newest *PooledFrame
+	for _, f := range p.latest {
+		if newest == nil || f.CapturedAt.After(newest.CapturedAt) {
+			newest = f
+		}
+	}
+	if newest == nil || time.Since(newest.CapturedAt) > maxAge {
+		return nil, false
+	}
+	return newest, true
+}
+
+// Remove drops a device's frame, e.g. when the device is disabled
+func (p *EntropyPool) Remove(deviceID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.latest, deviceID)
+}
+
+// FleetDigest hashes the fresh frames from every device, so a value drawn
+// from one lamp also depends on the rest of the fleet. It returns nil when no
+// device has pushed a frame within maxAge.
+func (p *EntropyPool) FleetDigest(maxAge time.Duration) []byte {
+	p.mu.RLock()
+	frames := make([]*PooledFrame, 0, len(p.latest))
+	for _, f := range p.latest {
+		if time.Since(f.CapturedAt) <= maxAge {
+			frames = append(frames, f)
+		}
+	}
+	p.mu.RUnlock()
+	if len(frames) == 0 {
+		return nil
+	}
+
+	// Order by device so the digest doesn't depend on map iteration
+	sort.Slice(frames, func(i, j int) bool {
+		return bytes.Compare(frames[i].DeviceID[:], frames[j].DeviceID[:]) < 0
+	})
+	h := sha256.New()
+	for _, f := range frames {
+		sum := sha256.Sum256(f.Data)
+		h.Write(f.DeviceID[:])
+		h.Write(sum[:])
+	}
+	return h.Sum(nil)
+}
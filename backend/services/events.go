@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// Event types pushed to clients
+const (
+	EventMFACode      = "mfa.code"
+	EventVaultChanged = "vault.changed"
+
+	EventPreferencesChanged = "preferences.changed"
+)
+
+// eventChannel is the Postgres LISTEN/NOTIFY channel shared by all instances
+const eventChannel = "lavalock_events"
+
+// Event is a single message delivered to subscribers.
+// A nil UserID means the event is broadcast to every subscriber.
+type Event struct {
+	Type   string          `json:"type"`
+	UserID *uuid.UUID      `json:"user_id,omitempty"`
+	Data   json.RawMessage `json:"data"`
+	Time   time.Time       `json:"time"`
+}
+
+// Subscription receives events for one connected client
+type Subscription struct {
+	UserID uuid.UUID
+	C      chan Event
+}
+
+// EventBus fans events out to connected clients. When backed by Postgres,
+// events are published with NOTIFY and every instance delivers them from its
+// LISTEN connection, so clients see events no matter which instance they hit.
+type EventBus struct {
+	mu        sync.RWMutex
+	subs      map[*Subscription]struct{}
+	db        *gorm.DB
+	listening atomic.Bool
+}
+
+func NewEventBus(db *gorm.DB) *EventBus {
+	bus := &EventBus{
+		subs: make(map[*Subscription]struct{}),
+		db:   db,
+	}
+	if db != nil {
+		go bus.listenLoop()
+	}
+	return bus
+}
+
+// Subscribe registers a client for events addressed to userID and broadcasts
+func (b *EventBus) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{UserID: userID, C: make(chan Event, 16)}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *EventBus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+// Publish sends an event to every instance. userID nil broadcasts to all users.
+func (b *EventBus) Publish(eventType string, userID *uuid.UUID, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("EventBus: failed to encode %s event: %v", eventType, err)
+		return
+	}
+	evt := Event{Type: eventType, UserID: userID, Data: raw, Time: time.Now()}
+
+	// Without a live LISTEN connection NOTIFY would go nowhere locally,
+	// so fall back to in-process delivery.
+	if b.db == nil || !b.listening.Load() {
+		b.dispatch(evt)
+		return
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		log.Printf("EventBus: failed to encode event: %v", err)
+		return
+	}
+	if err := b.db.Exec("SELECT pg_notify(?, ?)", eventChannel, string(payload)).Error; err != nil {
+		log.Printf("EventBus: NOTIFY failed, delivering locally: %v", err)
+		b.dispatch(evt)
+	}
+}
+
+// dispatch delivers to local subscribers. Slow clients drop events rather
+// than block the publisher.
+func (b *EventBus) dispatch(evt Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if evt.UserID != nil && *evt.UserID != sub.UserID {
+			continue
+		}
+		select {
+		case sub.C <- evt:
+		default:
+		}
+	}
+}
+
+// listenLoop holds a dedicated connection in LISTEN mode, reconnecting on failure
+func (b *EventBus) listenLoop() {
+	sqlDB, err := b.db.DB()
+	if err != nil {
+		log.Printf("EventBus: no SQL handle, using local delivery only: %v", err)
+		return
+	}
+
+	for {
+		if err := b.listen(sqlDB); err != nil {
+			log.Printf("EventBus: LISTEN connection lost: %v", err)
+		}
+		b.listening.Store(false)
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (b *EventBus) listen(sqlDB *sql.DB) error {
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+eventChannel); err != nil {
+			return err
+		}
+		b.listening.Store(true)
+		log.Printf("EventBus: listening on %s", eventChannel)
+
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			var evt Event
+			if err := json.Unmarshal([]byte(n.Payload), &evt); err != nil {
+				log.Printf("EventBus: dropping malformed notification: %v", err)
+				continue
+			}
+			b.dispatch(evt)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/bits"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+)
+
+// ThumbSize is the side of the grayscale thumbnail kept for frame comparisons
+const ThumbSize = 16
+
+// FrameStats describes a decoded frame for the quality checks
+type FrameStats struct {
+	Brightness float64 // mean luma, 0-255
+	Contrast   float64 // luma standard deviation
+	// Thumb is a ThumbSize x ThumbSize grayscale thumbnail, row-major
+	Thumb []byte
+	// DHash is a 64-bit difference hash; similar scenes have close hashes
+	DHash uint64
+	// Noise holds raw noise samples for the health tests: the least
+	// significant bit of evenly spaced pixels' luma, one bit per byte
+	Noise []byte
+}
+
+// maxNoiseSamples bounds how many noise samples are taken from one frame
+const maxNoiseSamples = 1 << 16
+
+// AnalyzeFrame decodes a JPEG and computes its quality statistics
+func AnalyzeFrame(data []byte) (*FrameStats, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode jpeg: %w", err)
+	}
+	b := img.Bounds()
+	if b.Dx() < ThumbSize || b.Dy() < ThumbSize {
+		return nil, fmt.Errorf("frame is too small (%dx%d)", b.Dx(), b.Dy())
+	}
+
+	var sum, sumSq float64
+	stride := max(b.Dx()*b.Dy()/maxNoiseSamples, 1)
+	noise := make([]byte, 0, min(b.Dx()*b.Dy(), maxNoiseSamples+1))
+	i := 0
+	thumb := make([]float64, ThumbSize*ThumbSize)
+	counts := make([]float64, ThumbSize*ThumbSize)
+	hashGrid := make([]float64, 9*8)
+	hashCounts := make([]float64, 9*8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		ty := (y - b.Min.Y) * ThumbSize / b.Dy()
+		hy := (y - b.Min.Y) * 8 / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			l := luma(img, x, y)
+			sum += l
+			sumSq += l * l
+			if i%stride == 0 {
+				noise = append(noise, byte(l)&1)
+			}
+			i++
+
+			cell := ty*ThumbSize + (x-b.Min.X)*ThumbSize/b.Dx()
+			thumb[cell] += l
+			counts[cell]++
+			hcell := hy*9 + (x-b.Min.X)*9/b.Dx()
+			hashGrid[hcell] += l
+			hashCounts[hcell]++
+		}
+	}
+
+	n := float64(b.Dx() * b.Dy())
+	mean := sum / n
+	stats := &FrameStats{
+		Brightness: mean,
+		Contrast:   math.Sqrt(math.Max(sumSq/n-mean*mean, 0)),
+		Thumb:      make([]byte, len(thumb)),
+		Noise:      noise,
+	}
+	for i := range thumb {
+		stats.Thumb[i] = byte(math.Round(thumb[i] / counts[i]))
+	}
+	// dHash: one bit per horizontally adjacent pair on a 9x8 grid
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			left := hashGrid[row*9+col] / hashCounts[row*9+col]
+			right := hashGrid[row*9+col+1] / hashCounts[row*9+col+1]
+			stats.DHash <<= 1
+			if left > right {
+				stats.DHash |= 1
+			}
+		}
+	}
+	return stats, nil
+}
+
+// luma reads a pixel's brightness, using the Y plane directly for the
+// YCbCr and Gray images image/jpeg produces
+func luma(img image.Image, x, y int) float64 {
+	switch m := img.(type) {
+	case *image.YCbCr:
+		return float64(m.Y[m.YOffset(x, y)])
+	case *image.Gray:
+		return float64(m.Pix[m.PixOffset(x, y)])
+	default:
+		return float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+	}
+}
+
+// ThumbDifference is the mean absolute per-pixel difference of two thumbnails
+func ThumbDifference(a, b []byte) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return math.Inf(1)
+	}
+	var total float64
+	for i := range a {
+		total += math.Abs(float64(a[i]) - float64(b[i]))
+	}
+	return total / float64(len(a))
+}
+
+// HashDistance is the number of differing bits between two dHashes
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// CheckFrame applies the quality thresholds to a frame and returns why it
+// was rejected, or "" if it passes. recent holds the device's latest accepted
+// frames, newest first; it may be empty.
+func CheckFrame(cfg *config.FrameQualityConfig, stats *FrameStats, recent []*FrameStats) string {
+	switch {
+	case stats.Brightness < cfg.MinBrightness:
+		return models.RejectTooDark
+	case stats.Brightness > cfg.MaxBrightness:
+		return models.RejectTooBright
+	case stats.Contrast < cfg.MinContrast:
+		return models.RejectLowContrast
+	}
+	if len(recent) > 0 && ThumbDifference(stats.Thumb, recent[0].Thumb) < cfg.MinDifference {
+		return models.RejectFrozen
+	}
+	if cfg.MinHashDistance > 0 {
+		for _, prev := range recent {
+			if HashDistance(stats.DHash, prev.DHash) < cfg.MinHashDistance {
+				return models.RejectSimilar
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Health states
+const (
+	HealthStartup = "startup" // startup tests not yet passed
+	HealthOK      = "ok"
+	HealthAlarm   = "alarm"
+)
+
+const (
+	// healthAlpha is the false positive probability per test (SP 800-90B 4.4)
+	healthAlpha = 1.0 / (1 << 20)
+	// aptWindow is the Adaptive Proportion Test window for binary samples
+	aptWindow = 1024
+)
+
+// ErrEntropyUnhealthy is returned while the entropy source is not trusted
+var ErrEntropyUnhealthy = errors.New("entropy source failed health tests")
+
+// HealthTestError describes a failed health test
+type HealthTestError struct {
+	Test   string // "rct" or "apt"
+	Count  int    // run length or window count that tripped the test
+	Cutoff int
+}
+
+func (e *HealthTestError) Error() string {
+	return fmt.Sprintf("%s failed: %d >= cutoff %d", e.Test, e.Count, e.Cutoff)
+}
+
+// EntropyHealthStatus is a snapshot of the health monitor
+type EntropyHealthStatus struct {
+	State         string    `json:"state"`
+	Reason        string    `json:"reason,omitempty"`
+	Since         time.Time `json:"since"`
+	Passed        int       `json:"consecutive_passes"`
+	StartupFrames int       `json:"startup_frames"`
+	Tested        int64     `json:"frames_tested"`
+	Failed        int64     `json:"frames_failed"`
+	RCTCutoff     int       `json:"rct_cutoff"`
+	APTCutoff     int       `json:"apt_cutoff"`
+	MinEntropy    float64   `json:"min_entropy"`
+}
+
+// EntropyHealth runs the NIST SP 800-90B continuous health tests (Repetition
+// Count and Adaptive Proportion) on noise samples from each frame. Any
+// failure raises an alarm; the source is trusted again only once
+// startupFrames consecutive frames pass, as it is after a restart.
+type EntropyHealth struct {
+	mu     sync.Mutex
+	status EntropyHealthStatus
+	// the last frame tested, so re-reading it doesn't count as a new pass
+	lastSource string
+	lastErr    error
+}
+
+// NewEntropyHealth derives the test cutoffs from the assessed min-entropy
+// per sample and runs the known-answer self-test
+func NewEntropyHealth(minEntropy float64, startupFrames int) (*EntropyHealth, error) {
+	h := &EntropyHealth{status: EntropyHealthStatus{
+		State:         HealthStartup,
+		Since:         time.Now(),
+		StartupFrames: startupFrames,
+		RCTCutoff:     1 + int(math.Ceil(-math.Log2(healthAlpha)/minEntropy)),
+		APTCutoff:     1 + critBinom(aptWindow, math.Pow(2, -minEntropy), healthAlpha),
+		MinEntropy:    minEntropy,
+	}}
+	if err := h.selfTest(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// selfTest checks that both tests trip on a stuck source and pass an
+// alternating one, so a broken test can't silently pass everything
+func (h *EntropyHealth) selfTest() error {
+	stuck := make([]byte, max(h.status.RCTCutoff, aptWindow))
+	if h.runTests(stuck) == nil {
+		return fmt.Errorf("health self-test: stuck samples passed")
+	}
+	alternating := make([]byte, 4*aptWindow)
+	for i := range alternating {
+		alternating[i] = byte(i & 1)
+	}
+	if err := h.runTests(alternating); err != nil {
+		return fmt.Errorf("health self-test: alternating samples failed: %v", err)
+	}
+	return nil
+}
+
+// runTests applies the RCT and APT to samples
+func (h *EntropyHealth) runTests(samples []byte) error {
+	rct, apt := h.status.RCTCutoff, h.status.APTCutoff
+
+	run := 0
+	for i, s := range samples {
+		if i > 0 && s == samples[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run >= rct {
+			return &HealthTestError{Test: "rct", Count: run, Cutoff: rct}
+		}
+	}
+
+	for start := 0; start+aptWindow <= len(samples); start += aptWindow {
+		window := samples[start : start+aptWindow]
+		count := 0
+		for _, s := range window {
+			if s == window[0] {
+				count++
+			}
+		}
+		if count >= apt {
+			return &HealthTestError{Test: "apt", Count: count, Cutoff: apt}
+		}
+	}
+	return nil
+}
+
+// Test runs the health tests on one frame's noise samples and updates the
+// alarm state. source identifies the frame (its S3 key); testing the same
+// frame twice in a row returns the earlier result. It returns the test
+// failure, if any.
+func (h *EntropyHealth) Test(source string, samples []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if source == h.lastSource {
+		return h.lastErr
+	}
+	err := h.runTests(samples)
+	h.lastSource, h.lastErr = source, err
+
+	h.status.Tested++
+	if err != nil {
+		h.status.Failed++
+		h.status.Passed = 0
+		if h.status.State != HealthAlarm {
+			log.Printf("Entropy health: ALARM from %s: %v", source, err)
+			h.status.State, h.status.Since = HealthAlarm, time.Now()
+		}
+		h.status.Reason = source + ": " + err.Error()
+		return err
+	}
+
+	h.status.Passed++
+	if h.status.State != HealthOK && h.status.Passed >= h.status.StartupFrames {
+		log.Printf("Entropy health: %d consecutive frames passed, source trusted", h.status.Passed)
+		h.status.State, h.status.Since, h.status.Reason = HealthOK, time.Now(), ""
+	}
+	return nil
+}
+
+// Ready returns ErrEntropyUnhealthy unless the source is currently trusted
+func (h *EntropyHealth) Ready() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status.State != HealthOK {
+		return ErrEntropyUnhealthy
+	}
+	return nil
+}
+
+// Status returns a snapshot of the monitor
+func (h *EntropyHealth) Status() EntropyHealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+// critBinom returns the smallest c such that P(X > c) <= alpha for
+// X ~ Binomial(n, p), i.e. CRITBINOM(n, p, 1-alpha)
+func critBinom(n int, p, alpha float64) int {
+	logPMF := func(k int) float64 {
+		lnN, _ := math.Lgamma(float64(n + 1))
+		lnK, _ := math.Lgamma(float64(k + 1))
+		lnNK, _ := math.Lgamma(float64(n - k + 1))
+		return lnN - lnK - lnNK + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p)
+	}
+	tail := 0.0
+	for c := n; c > 0; c-- {
+		tail += math.Exp(logPMF(c))
+		if tail > alpha {
+			return c
+		}
+	}
+	return 0
+}
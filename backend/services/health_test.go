@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// spreadOnes returns one APT window holding the given number of ones, spread
+// out so no run gets anywhere near the RCT cutoff
+func spreadOnes(ones int) []byte {
+	w := make([]byte, aptWindow)
+	for i := range w {
+		w[i] = byte((i+1)*ones/aptWindow - i*ones/aptWindow)
+	}
+	return w
+}
+
+func alternating(n int) []byte {
+	s := make([]byte, n)
+	for i := range s {
+		s[i] = byte(i & 1)
+	}
+	return s
+}
+
+func newTestHealth(t *testing.T, startupFrames int) *EntropyHealth {
+	t.Helper()
+	h, err := NewEntropyHealth(1, startupFrames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func healthTestName(err error) string {
+	var he *HealthTestError
+	if errors.As(err, &he) {
+		return he.Test
+	}
+	return ""
+}
+
+// Cutoffs for binary samples at alpha = 2^-20, as tabulated in SP 800-90B
+func TestHealthCutoffs(t *testing.T) {
+	tests := []struct {
+		minEntropy float64
+		rct, apt   int
+	}{
+		{1, 21, 589},
+		{0.2, 101, 941},
+	}
+	for _, tt := range tests {
+		h, err := NewEntropyHealth(tt.minEntropy, 1)
+		if err != nil {
+			t.Fatalf("H=%v: %v", tt.minEntropy, err)
+		}
+		s := h.Status()
+		if s.RCTCutoff != tt.rct || s.APTCutoff != tt.apt {
+			t.Errorf("H=%v: cutoffs RCT %d, APT %d; want %d, %d",
+				tt.minEntropy, s.RCTCutoff, s.APTCutoff, tt.rct, tt.apt)
+		}
+	}
+}
+
+func TestHealthStuckSourceTripsRCT(t *testing.T) {
+	h := newTestHealth(t, 1)
+	rct := h.Status().RCTCutoff
+
+	// A run one short of the cutoff passes; one more repeat trips it
+	samples := alternating(2 * aptWindow)
+	for i := 100; i < 100+rct-1; i++ {
+		samples[i] = 0
+	}
+	samples[100+rct-1] = 1
+	if err := h.runTests(samples); err != nil {
+		t.Fatalf("run of %d tripped: %v", rct-1, err)
+	}
+	samples[100+rct-1] = 0
+	if err := h.runTests(samples); healthTestName(err) != "rct" {
+		t.Fatalf("run of %d: got %v, want an rct failure", rct, err)
+	}
+
+	if err := h.Test("stuck", make([]byte, aptWindow)); healthTestName(err) != "rct" {
+		t.Fatalf("stuck frame: got %v, want an rct failure", err)
+	}
+	if h.Status().State != HealthAlarm || h.Ready() != ErrEntropyUnhealthy {
+		t.Error("a stuck frame didn't raise the alarm")
+	}
+}
+
+func TestHealthBiasedSourceTripsAPT(t *testing.T) {
+	h := newTestHealth(t, 1)
+	apt := h.Status().APTCutoff
+
+	// cutoff-1 zeros in the window passes, cutoff zeros trips
+	if err := h.runTests(spreadOnes(aptWindow - apt + 1)); err != nil {
+		t.Fatalf("%d of %d tripped: %v", apt-1, aptWindow, err)
+	}
+	biased := spreadOnes(aptWindow - apt)
+	if err := h.runTests(biased); healthTestName(err) != "apt" {
+		t.Fatalf("%d of %d: got %v, want an apt failure", apt, aptWindow, err)
+	}
+
+	// Only whole windows are tested, and a biased later window still trips
+	frame := append(alternating(aptWindow), biased...)
+	if err := h.Test("biased", frame); healthTestName(err) != "apt" {
+		t.Fatalf("biased frame: got %v, want an apt failure", err)
+	}
+	if err := h.runTests(append(alternating(aptWindow), biased[:aptWindow-1]...)); err != nil {
+		t.Fatalf("partial window was tested: %v", err)
+	}
+}
+
+func TestHealthRecoveryNeedsStartupFrames(t *testing.T) {
+	const startup = 3
+	h := newTestHealth(t, startup)
+	good := alternating(2 * aptWindow)
+	frame := 0
+	pass := func() {
+		t.Helper()
+		frame++
+		if err := h.Test(fmt.Sprintf("frame-%d", frame), good); err != nil {
+			t.Fatalf("good frame failed: %v", err)
+		}
+	}
+
+	for i := 1; i < startup; i++ {
+		pass()
+		if h.Ready() == nil {
+			t.Fatalf("trusted after %d of %d startup frames", i, startup)
+		}
+	}
+	pass()
+	if h.Ready() != nil {
+		t.Fatal("not trusted after the startup frames")
+	}
+
+	if h.Test("bad", make([]byte, aptWindow)) == nil {
+		t.Fatal("stuck frame passed")
+	}
+	for i := 1; i < startup; i++ {
+		pass()
+		if s := h.Status(); s.State != HealthAlarm || s.Passed != i {
+			t.Fatalf("after %d passes: state %s, passed %d", i, s.State, s.Passed)
+		}
+	}
+	pass()
+	s := h.Status()
+	if s.State != HealthOK || s.Reason != "" {
+		t.Fatalf("after recovery: state %s, reason %q", s.State, s.Reason)
+	}
+	if s.Tested != 2*startup+1 || s.Failed != 1 {
+		t.Errorf("tested %d, failed %d; want %d, 1", s.Tested, s.Failed, 2*startup+1)
+	}
+}
+
+func TestHealthRetestingFrameIsMemoized(t *testing.T) {
+	h := newTestHealth(t, 2)
+	good := alternating(aptWindow)
+
+	// Re-reading the same passing frame doesn't count towards startup
+	for i := 0; i < 3; i++ {
+		if err := h.Test("frame-1", good); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := h.Status(); s.Tested != 1 || s.Passed != 1 || s.State != HealthStartup {
+		t.Fatalf("tested %d, passed %d, state %s; want 1, 1, startup", s.Tested, s.Passed, s.State)
+	}
+
+	// and a failed frame keeps its result
+	if h.Test("frame-2", make([]byte, aptWindow)) == nil {
+		t.Fatal("stuck frame passed")
+	}
+	if h.Test("frame-2", good) == nil {
+		t.Fatal("re-testing the failed frame cleared its failure")
+	}
+	if s := h.Status(); s.Tested != 2 || s.Failed != 1 {
+		t.Errorf("tested %d, failed %d; want 2, 1", s.Tested, s.Failed)
+	}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
@@ -24,15 +26,8 @@ func (s *KeyGenService) GeneratePassword(imageData []byte, length int) string {
 		length = 16
 	}
 
-	hash := sha256.Sum256(imageData)
 	password := ""
-	currentHash := hash
-
-	for i := 0; i < length; i++ {
-		if i > 0 && i%32 == 0 {
-			currentHash = sha256.Sum256(currentHash[:])
-		}
-		b := currentHash[i%32]
+	for _, b := range s.OutputBytes(imageData, length) {
 		idx := int(b) % len(CharsetAll)
 		password += string(CharsetAll[idx])
 	}
@@ -40,6 +35,21 @@ func (s *KeyGenService) GeneratePassword(imageData []byte, length int) string {
 	return password
 }
 
+// OutputBytes returns the n bytes GeneratePassword maps onto the charset:
+// the SHA-256 of the image, re-hashed for every further 32 bytes. The
+// randomness tests read the generator's output from here.
+func (s *KeyGenService) OutputBytes(imageData []byte, n int) []byte {
+	out := make([]byte, 0, n)
+	currentHash := sha256.Sum256(imageData)
+	for len(out) < n {
+		if len(out) > 0 {
+			currentHash = sha256.Sum256(currentHash[:])
+		}
+		out = append(out, currentHash[:min(32, n-len(out))]...)
+	}
+	return out
+}
+
 func (s *KeyGenService) CalculateEntropyEstimate(password string) int {
 	return int(float64(len(password)) * 6.1)
 }
@@ -52,3 +62,30 @@ func (s *KeyGenService) GenerateMFACode(imageData []byte) string {
 	code := val % 1000000
 	return fmt.Sprintf("%06d", code)
 }
+
+// DeriveSecret derives n bytes of key material from a lava lamp frame.
+// The frame is mixed with OS randomness because source images sit in a
+// bucket that other parties may be able to read.
+func (s *KeyGenService) DeriveSecret(imageData []byte, n int) ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// HKDF-style extract-then-expand with SHA-256
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(imageData)
+	prk := extract.Sum(nil)
+
+	out := make([]byte, 0, n)
+	var block []byte
+	for counter := byte(1); len(out) < n; counter++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(block)
+		expand.Write([]byte("lavalock-secret"))
+		expand.Write([]byte{counter})
+		block = expand.Sum(nil)
+		out = append(out, block...)
+	}
+	return out[:n], nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/google/uuid"
+)
+
+// Mixer source names; each camera is its own source
+const (
+	MixerSourceOS        = "os"
+	MixerSourceWallpaper = "wallpaper"
+	mixerCameraPrefix    = "camera:"
+)
+
+// ErrInsufficientEntropy is returned until enough entropy has been credited
+var ErrInsufficientEntropy = errors.New("not enough entropy has been collected")
+
+// MixerSourceStats is one source's contribution to the mixer
+type MixerSourceStats struct {
+	Name          string    `json:"name"`
+	Contributions int64     `json:"contributions"`
+	Duplicates    int64     `json:"duplicates"` // inputs ignored as repeats
+	Bytes         int64     `json:"bytes"`
+	TotalCredit   float64   `json:"total_credit_bits"` // credited over the mixer's lifetime
+	RawCredit     float64   `json:"raw_credit_bits"`   // credited since the last extraction
+	Credit        float64   `json:"credit_bits"`       // RawCredit after the per-source cap
+	Share         float64   `json:"share"`             // of the remaining credit
+	LastAt        time.Time `json:"last_contribution_at"`
+	lastDigest    [sha256.Size]byte
+}
+
+// EntropyMixerStatus is reported by the admin endpoint
+type EntropyMixerStatus struct {
+	Ready          bool               `json:"ready"`       // whether the next extraction would succeed
+	Credit         float64            `json:"credit_bits"` // remaining since the last extraction
+	Threshold      float64            `json:"threshold_bits"`
+	MaxSourceShare float64            `json:"max_source_share"`
+	Extractions    int64              `json:"extractions"`
+	Sources        []MixerSourceStats `json:"sources"`
+}
+
+// EntropyMixer hashes every source's input into one pool and credits each
+// source a conservative min-entropy estimate. Every extraction needs the
+// credited total to reach the threshold and then uses it all up, with each
+// source's credit capped so at least two sources contribute to every draw.
+// Because inputs are hashed in rather than combined, a source that controls
+// its own input (a malicious AI response, a tampered camera) still can't
+// steer the output while any other source is unpredictable to it.
+type EntropyMixer struct {
+	cfg         *config.EntropyMixerConfig
+	mu          sync.Mutex
+	pool        []byte
+	seq         uint64
+	extractions int64
+	sources     map[string]*MixerSourceStats
+}
+
+func NewEntropyMixer(cfg *config.EntropyMixerConfig) *EntropyMixer {
+	return &EntropyMixer{
+		cfg:     cfg,
+		pool:    make([]byte, sha512.Size),
+		sources: make(map[string]*MixerSourceStats),
+	}
+}
+
+// AddFrame mixes in a camera frame; uuid.Nil is for frames of unknown origin
+func (m *EntropyMixer) AddFrame(deviceID uuid.UUID, data []byte) {
+	name := mixerCameraPrefix + deviceID.String()
+	if deviceID == uuid.Nil {
+		name = mixerCameraPrefix + "legacy"
+	}
+	m.Add(name, data, m.cfg.CameraCredit)
+}
+
+// AddWallpaper mixes in an AI-generated wallpaper
+func (m *EntropyMixer) AddWallpaper(data []byte) {
+	m.Add(MixerSourceWallpaper, data, m.cfg.WallpaperCredit)
+}
+
+// Add mixes data from source into the pool and credits it. Repeating the
+// source's previous input earns nothing.
+func (m *EntropyMixer) Add(source string, data []byte, credit float64) {
+	digest := sha256.Sum256(data)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sources[source]
+	if !ok {
+		s = &MixerSourceStats{Name: source}
+		m.sources[source] = s
+	}
+	if s.Contributions > 0 && s.lastDigest == digest {
+		s.Duplicates++
+		return
+	}
+
+	inner := sha512.Sum512(data)
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(source)))
+	binary.BigEndian.PutUint64(header[4:], m.seq)
+	h := sha512.New()
+	h.Write(m.pool)
+	h.Write([]byte("lavalock-mix-add"))
+	h.Write(header[:])
+	h.Write([]byte(source))
+	h.Write(inner[:])
+	m.pool = h.Sum(nil)
+	m.seq++
+
+	s.Contributions++
+	s.Bytes += int64(len(data))
+	s.TotalCredit += credit
+	s.RawCredit += credit
+	s.LastAt = time.Now()
+	s.lastDigest = digest
+}
+
+// credit is the capped credit of a source; callers hold mu
+func (m *EntropyMixer) credit(s *MixerSourceStats) float64 {
+	return min(s.RawCredit, m.cfg.Threshold*m.cfg.MaxSourceShare)
+}
+
+func (m *EntropyMixer) totalCredit() float64 {
+	total := 0.0
+	for _, s := range m.sources {
+		total += m.credit(s)
+	}
+	return total
+}
+
+// ready reports whether an extraction would succeed once it has mixed in
+// its OS input; callers hold mu
+func (m *EntropyMixer) ready() bool {
+	total := m.totalCredit()
+	limit := m.cfg.Threshold * m.cfg.MaxSourceShare
+	if s, ok := m.sources[MixerSourceOS]; ok {
+		total += min(s.RawCredit+m.cfg.OSCredit, limit) - m.credit(s)
+	} else {
+		total += min(m.cfg.OSCredit, limit)
+	}
+	return total >= m.cfg.Threshold
+}
+
+// Ready reports whether Extract would succeed now. Callers use it to avoid
+// costly work that would only be refused.
+func (m *EntropyMixer) Ready() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ready()
+}
+
+// Extract mixes in fresh OS randomness and returns n bytes derived from the
+// pool, then ratchets the pool so earlier output can't be recovered from it.
+// The credit is used up: the next extraction needs the threshold again.
+func (m *EntropyMixer) Extract(n int) ([]byte, error) {
+	osInput := make([]byte, 32)
+	if _, err := rand.Read(osInput); err != nil {
+		return nil, err
+	}
+	m.Add(MixerSourceOS, osInput, m.cfg.OSCredit)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.totalCredit() < m.cfg.Threshold {
+		return nil, ErrInsufficientEntropy
+	}
+
+	out := make([]byte, 0, n)
+	for counter := uint32(1); len(out) < n; counter++ {
+		mac := hmac.New(sha512.New, m.pool)
+		mac.Write([]byte("lavalock-mix-extract"))
+		binary.Write(mac, binary.BigEndian, counter)
+		out = append(out, mac.Sum(nil)...)
+	}
+	ratchet := sha512.New()
+	ratchet.Write(m.pool)
+	ratchet.Write([]byte("lavalock-mix-ratchet"))
+	m.pool = ratchet.Sum(nil)
+	m.extractions++
+	for _, s := range m.sources {
+		s.RawCredit = 0
+	}
+	return out[:n], nil
+}
+
+// Status reports the credited entropy and each source's contribution
+func (m *EntropyMixer) Status() EntropyMixerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := m.totalCredit()
+	status := EntropyMixerStatus{
+		Ready:          m.ready(),
+		Credit:         total,
+		Threshold:      m.cfg.Threshold,
+		MaxSourceShare: m.cfg.MaxSourceShare,
+		Extractions:    m.extractions,
+		Sources:        make([]MixerSourceStats, 0, len(m.sources)),
+	}
+	for _, s := range m.sources {
+		stats := *s
+		stats.Credit = m.credit(s)
+		if total > 0 {
+			stats.Share = stats.Credit / total
+		}
+		status.Sources = append(status.Sources, stats)
+	}
+	sort.Slice(status.Sources, func(i, j int) bool {
+		return status.Sources[i].Name < status.Sources[j].Name
+	})
+	return status
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/google/uuid"
+)
+
+func testMixerConfig() *config.EntropyMixerConfig {
+	return &config.EntropyMixerConfig{
+		Threshold:      256,
+		MaxSourceShare: 0.5,
+		CameraCredit:   32,
+		OSCredit:       128,
+	}
+}
+
+func addFrames(m *EntropyMixer, device uuid.UUID, n int) {
+	for i := 0; i < n; i++ {
+		m.AddFrame(device, []byte(fmt.Sprintf("%s frame %d", device, i)))
+	}
+}
+
+func TestMixerOSAloneNeverSuffices(t *testing.T) {
+	m := NewEntropyMixer(testMixerConfig())
+	for i := 0; i < 5; i++ {
+		if m.Ready() {
+			t.Fatal("Ready with only OS input")
+		}
+		if _, err := m.Extract(32); err != ErrInsufficientEntropy {
+			t.Fatalf("Extract with only OS input: got %v, want ErrInsufficientEntropy", err)
+		}
+	}
+}
+
+func TestMixerThreshold(t *testing.T) {
+	m := NewEntropyMixer(testMixerConfig())
+	camera := uuid.New()
+
+	// OS (128) plus three frames (96) is short of 256
+	addFrames(m, camera, 3)
+	if m.Ready() {
+		t.Fatal("Ready below the threshold")
+	}
+	if _, err := m.Extract(32); err != ErrInsufficientEntropy {
+		t.Fatalf("Extract below the threshold: got %v", err)
+	}
+
+	addFrames(m, uuid.New(), 1)
+	if !m.Ready() {
+		t.Fatal("not Ready at the threshold")
+	}
+	out, err := m.Extract(100)
+	if err != nil {
+		t.Fatalf("Extract at the threshold: %v", err)
+	}
+	if len(out) != 100 {
+		t.Fatalf("Extract returned %d bytes, want 100", len(out))
+	}
+}
+
+func TestMixerExtractUsesUpCredit(t *testing.T) {
+	m := NewEntropyMixer(testMixerConfig())
+	addFrames(m, uuid.New(), 4)
+	first, err := m.Extract(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Ready() {
+		t.Fatal("Ready straight after an extraction")
+	}
+	if _, err := m.Extract(32); err != ErrInsufficientEntropy {
+		t.Fatalf("second Extract without new input: got %v", err)
+	}
+	if status := m.Status(); status.Extractions != 1 {
+		t.Errorf("Extractions = %d, want 1", status.Extractions)
+	}
+
+	addFrames(m, uuid.New(), 4)
+	second, err := m.Extract(32)
+	if err != nil {
+		t.Fatalf("Extract after new input: %v", err)
+	}
+	if string(first) == string(second) {
+		t.Error("extractions repeated output")
+	}
+}
+
+func TestMixerDuplicatesEarnNothing(t *testing.T) {
+	m := NewEntropyMixer(testMixerConfig())
+	camera := uuid.New()
+	for i := 0; i < 8; i++ {
+		m.AddFrame(camera, []byte("the same frame"))
+	}
+	if m.Ready() {
+		t.Fatal("repeated frames were credited")
+	}
+	status := m.Status()
+	if len(status.Sources) != 1 {
+		t.Fatalf("got %d sources, want 1", len(status.Sources))
+	}
+	s := status.Sources[0]
+	if s.Contributions != 1 || s.Duplicates != 7 || s.Credit != 32 {
+		t.Errorf("got %d contributions, %d duplicates, %v bits; want 1, 7, 32",
+			s.Contributions, s.Duplicates, s.Credit)
+	}
+}
+
+func TestMixerSourceCap(t *testing.T) {
+	cfg := testMixerConfig()
+	cfg.OSCredit = 0
+	m := NewEntropyMixer(cfg)
+	camera := uuid.New()
+
+	// One camera alone is capped at half the threshold
+	addFrames(m, camera, 20)
+	if m.Ready() {
+		t.Fatal("one source satisfied the threshold alone")
+	}
+	if _, err := m.Extract(32); err != ErrInsufficientEntropy {
+		t.Fatalf("Extract from one source: got %v", err)
+	}
+
+	addFrames(m, uuid.New(), 4)
+	if !m.Ready() {
+		t.Fatal("not Ready with two capped sources")
+	}
+	if _, err := m.Extract(32); err != nil {
+		t.Fatalf("Extract from two sources: %v", err)
+	}
+}
+
+func TestMixerStatusReportsRemainingCredit(t *testing.T) {
+	m := NewEntropyMixer(testMixerConfig())
+	camera := uuid.New()
+	addFrames(m, camera, 10)
+
+	status := m.Status()
+	if status.Credit != 128 {
+		t.Errorf("Credit = %v, want 128 (capped)", status.Credit)
+	}
+	s := status.Sources[0]
+	if s.RawCredit != 320 || s.TotalCredit != 320 || s.Share != 1 {
+		t.Errorf("got raw %v, total %v, share %v; want 320, 320, 1", s.RawCredit, s.TotalCredit, s.Share)
+	}
+	if !status.Ready {
+		t.Error("Status not Ready although OS input would complete the threshold")
+	}
+
+	if _, err := m.Extract(32); err != nil {
+		t.Fatal(err)
+	}
+	status = m.Status()
+	if status.Credit != 0 || status.Ready {
+		t.Errorf("after extraction: Credit = %v, Ready = %v; want 0, false", status.Credit, status.Ready)
+	}
+	for _, s := range status.Sources {
+		if s.RawCredit != 0 {
+			t.Errorf("%s kept %v bits after extraction", s.Name, s.RawCredit)
+		}
+	}
+	if got := status.Sources[0].TotalCredit; got != 320 {
+		t.Errorf("lifetime credit = %v, want 320", got)
+	}
+}
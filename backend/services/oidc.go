@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jwksCacheTTL       = time.Hour
+	jwksMinRefetch     = time.Minute
+	idTokenClockSkew   = time.Minute
+	oidcRequestTimeout = 10 * time.Second
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrIDTokenInvalid  = errors.New("invalid ID token")
+)
+
+// IDTokenClaims are the verified claims we use from an OIDC ID token
+type IDTokenClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	IssuedAt      time.Time
+	ExpiresAt     time.Time
+}
+
+// OIDCProvider verifies ID tokens from one OpenID Connect issuer.
+// Discovery and JWKS are fetched lazily and cached.
+type OIDCProvider struct {
+	Name      string
+	Issuer    string
+	ClientIDs []string
+	issuers   []string
+
+	client *http.Client
+
+	mu          sync.Mutex
+	jwksURI     string
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+func NewOIDCProvider(name, issuer string, clientIDs, extraIssuers []string) *OIDCProvider {
+	return &OIDCProvider{
+		Name:      name,
+		Issuer:    issuer,
+		ClientIDs: clientIDs,
+		issuers:   append([]string{issuer}, extraIssuers...),
+		client:    &http.Client{Timeout: oidcRequestTimeout},
+	}
+}
+
+// OIDCRegistry holds the configured providers by name
+type OIDCRegistry struct {
+	providers map[string]*OIDCProvider
+}
+
+func NewOIDCRegistry(providers ...*OIDCProvider) *OIDCRegistry {
+	r := &OIDCRegistry{providers: make(map[string]*OIDCProvider)}
+	for _, p := range providers {
+		r.providers[p.Name] = p
+	}
+	return r
+}
+
+func (r *OIDCRegistry) Get(name string) (*OIDCProvider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names lists the configured provider names
+func (r *OIDCRegistry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Verify checks an ID token's signature, issuer, audience and expiry
+func (p *OIDCProvider) Verify(ctx context.Context, rawToken string) (*IDTokenClaims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrIDTokenInvalid)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: bad header", ErrIDTokenInvalid)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrIDTokenInvalid)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Iss           string          `json:"iss"`
+		Sub           string          `json:"sub"`
+		Aud           json.RawMessage `json:"aud"`
+		Exp           int64           `json:"exp"`
+		Nbf           int64           `json:"nbf"`
+		Iat           int64           `json:"iat"`
+		Email         string          `json:"email"`
+		EmailVerified interface{}     `json:"email_verified"`
+		Name          string          `json:"name"`
+	}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("%w: bad claims", ErrIDTokenInvalid)
+	}
+
+	if !contains(p.issuers, raw.Iss) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrIDTokenInvalid, raw.Iss)
+	}
+	if raw.Sub == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrIDTokenInvalid)
+	}
+	if !p.audienceAllowed(raw.Aud) {
+		return nil, fmt.Errorf("%w: audience not allowed", ErrIDTokenInvalid)
+	}
+	now := time.Now()
+	exp := time.Unix(raw.Exp, 0)
+	if raw.Exp == 0 || now.After(exp.Add(idTokenClockSkew)) {
+		return nil, fmt.Errorf("%w: expired", ErrIDTokenInvalid)
+	}
+	if raw.Nbf != 0 && now.Add(idTokenClockSkew).Before(time.Unix(raw.Nbf, 0)) {
+		return nil, fmt.Errorf("%w: not yet valid", ErrIDTokenInvalid)
+	}
+
+	// Apple sends email_verified as the string "true"
+	verified := false
+	switch v := raw.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+
+	return &IDTokenClaims{
+		Issuer:        raw.Iss,
+		Subject:       raw.Sub,
+		Email:         raw.Email,
+		EmailVerified: verified,
+		Name:          raw.Name,
+		IssuedAt:      time.Unix(raw.Iat, 0),
+		ExpiresAt:     exp,
+	}, nil
+}
+
+func (p *OIDCProvider) audienceAllowed(raw json.RawMessage) bool {
+	var auds []string
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		auds = []string{single}
+	} else if err := json.Unmarshal(raw, &auds); err != nil {
+		return false
+	}
+	for _, aud := range auds {
+		if contains(p.ClientIDs, aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the signing key for kid, refetching the JWKS when the cache is
+// stale or the kid is unknown (providers rotate keys without notice)
+func (p *OIDCProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok && time.Since(p.keysFetched) < jwksCacheTTL {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < jwksMinRefetch {
+		if key, ok := p.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: unknown key id", ErrIDTokenInvalid)
+	}
+
+	if err := p.refreshKeys(ctx); err != nil {
+		// Fall back to a stale cached key rather than failing every login
+		if key, ok := p.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key id", ErrIDTokenInvalid)
+}
+
+// refreshKeys runs discovery (once) and reloads the JWKS. Caller holds p.mu.
+func (p *OIDCProvider) refreshKeys(ctx context.Context) error {
+	p.keysFetched = time.Now()
+
+	if p.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := p.getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("OIDC discovery for %s failed: %w", p.Name, err)
+		}
+		if discovery.JWKSURI == "" {
+			return fmt.Errorf("OIDC discovery for %s returned no jwks_uri", p.Name)
+		}
+		p.jwksURI = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.jwksURI, &jwks); err != nil {
+		return fmt.Errorf("JWKS fetch for %s failed: %w", p.Name, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	p.keys = keys
+	return nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], sig) != nil {
+			return fmt.Errorf("%w: bad signature", ErrIDTokenInvalid)
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return fmt.Errorf("%w: bad signature", ErrIDTokenInvalid)
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return fmt.Errorf("%w: bad signature", ErrIDTokenInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrIDTokenInvalid, alg)
+	}
+	return nil
+}
+
+func decodeSegment(seg string, out interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/services/oidctest"
+)
+
+const testClientID = "lavalock-test"
+
+func newTestProvider(t *testing.T) (*oidctest.Issuer, *OIDCProvider) {
+	t.Helper()
+	iss, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(iss.Close)
+	return iss, NewOIDCProvider("test", iss.URL(), []string{testClientID}, nil)
+}
+
+func mint(t *testing.T, iss *oidctest.Issuer, clientID string, extra map[string]interface{}) string {
+	t.Helper()
+	token, err := iss.Mint(clientID, "user-1", extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// withHeader swaps a token's header, keeping its claims and signature
+func withHeader(token, header string) string {
+	parts := strings.SplitN(token, ".", 2)
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + parts[1]
+}
+
+func TestOIDCVerify(t *testing.T) {
+	iss, p := newTestProvider(t)
+	claims, err := p.Verify(context.Background(), mint(t, iss, testClientID, map[string]interface{}{"name": "Ada"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != iss.URL() || claims.Subject != "user-1" || claims.Name != "Ada" ||
+		claims.Email != "user-1@oidctest.local" || !claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// Apple's string form of email_verified, and an audience list
+	claims, err = p.Verify(context.Background(), mint(t, iss, testClientID, map[string]interface{}{
+		"email_verified": "true",
+		"aud":            []string{"someone-else", testClientID},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.EmailVerified {
+		t.Error(`email_verified "true" not accepted`)
+	}
+}
+
+func TestOIDCVerifyRejects(t *testing.T) {
+	iss, p := newTestProvider(t)
+	now := time.Now()
+	valid := mint(t, iss, testClientID, nil)
+	parts := strings.Split(valid, ".")
+	kid := `"kid":"oidctest-1"`
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong issuer", mint(t, iss, testClientID, map[string]interface{}{"iss": "https://accounts.example.com"})},
+		{"wrong audience", mint(t, iss, "another-client", nil)},
+		{"no audience match in list", mint(t, iss, testClientID, map[string]interface{}{"aud": []string{"a", "b"}})},
+		{"missing subject", mint(t, iss, testClientID, map[string]interface{}{"sub": ""})},
+		{"expired", mint(t, iss, testClientID, map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()})},
+		{"no expiry", mint(t, iss, testClientID, map[string]interface{}{"exp": 0})},
+		{"not yet valid", mint(t, iss, testClientID, map[string]interface{}{"nbf": now.Add(5 * time.Minute).Unix()})},
+		{"alg none", withHeader(parts[0]+"."+parts[1]+".", `{"alg":"none",`+kid+`}`)},
+		{"alg HS256", withHeader(valid, `{"alg":"HS256",`+kid+`}`)},
+		{"alg ES256 with an RSA key", withHeader(valid, `{"alg":"ES256",`+kid+`}`)},
+		{"tampered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + "." + parts[2]},
+		{"malformed", parts[0] + "." + parts[1]},
+	}
+	for _, tt := range tests {
+		if _, err := p.Verify(context.Background(), tt.token); !errors.Is(err, ErrIDTokenInvalid) {
+			t.Errorf("%s: got %v, want ErrIDTokenInvalid", tt.name, err)
+		}
+	}
+
+	// An expiry within the allowed clock skew still passes
+	token := mint(t, iss, testClientID, map[string]interface{}{"exp": now.Add(-idTokenClockSkew / 2).Unix()})
+	if _, err := p.Verify(context.Background(), token); err != nil {
+		t.Errorf("token inside the clock skew rejected: %v", err)
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	iss, p := newTestProvider(t)
+	ctx := context.Background()
+	oldToken := mint(t, iss, testClientID, nil)
+	if _, err := p.Verify(ctx, oldToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := iss.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	newToken := mint(t, iss, testClientID, nil)
+
+	// The JWKS was just fetched, so an unknown kid doesn't refetch yet
+	if _, err := p.Verify(ctx, newToken); !errors.Is(err, ErrIDTokenInvalid) {
+		t.Fatalf("unknown kid inside the refetch interval: got %v", err)
+	}
+
+	// Once the interval has passed, the unknown kid triggers a refresh
+	p.mu.Lock()
+	p.keysFetched = time.Now().Add(-2 * jwksMinRefetch)
+	p.mu.Unlock()
+	if _, err := p.Verify(ctx, newToken); err != nil {
+		t.Fatalf("token from the rotated key: %v", err)
+	}
+	// The retired key is gone from the refreshed set
+	if _, err := p.Verify(ctx, oldToken); !errors.Is(err, ErrIDTokenInvalid) {
+		t.Errorf("token from the retired key: got %v", err)
+	}
+}
+
+func TestOIDCRegistry(t *testing.T) {
+	_, p := newTestProvider(t)
+	r := NewOIDCRegistry(p)
+	if got, err := r.Get("test"); err != nil || got != p {
+		t.Errorf("Get(test) = %v, %v", got, err)
+	}
+	if _, err := r.Get("missing"); err != ErrUnknownProvider {
+		t.Errorf("Get(missing): got %v, want ErrUnknownProvider", err)
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ProvenanceNonceBytes is the size of the secret nonce mixed into each
+// generated password
+const ProvenanceNonceBytes = 32
+
+// NewProvenanceNonce returns a fresh server nonce
+func NewProvenanceNonce() ([]byte, error) {
+	nonce := make([]byte, ProvenanceNonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// NonceCommitment commits to a nonce without revealing it
+func NonceCommitment(nonce []byte) string {
+	h := sha256.New()
+	h.Write([]byte("lavalock-provenance-nonce"))
+	h.Write(nonce)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PasswordSeed is what GeneratePassword is fed: the wallpaper and fleet
+// digest keyed by the nonce, so someone who can read the buckets still can't
+// reproduce the password
+func PasswordSeed(nonce, wallpaper, fleetDigest []byte) []byte {
+	return KeyedSeed("lavalock-password", nonce, wallpaper, fleetDigest)
+}
+
+// KeyedSeed derives a generator's input from an image and the fleet digest,
+// keyed by mixer output so neither the image nor its source can choose it
+func KeyedSeed(purpose string, key, image, fleetDigest []byte) []byte {
+	sum := sha256.Sum256(image)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	mac.Write(sum[:])
+	mac.Write(fleetDigest)
+	return mac.Sum(nil)
+}
+
+// OutputCommitment binds a password to the nonce it was generated with
+func OutputCommitment(nonce []byte, password string) string {
+	mac := hmac.New(sha256.New, nonce)
+	mac.Write([]byte("lavalock-provenance-output"))
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SHA256Hex is the hex SHA-256 of data
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
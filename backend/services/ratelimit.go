@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/config"
+	"github.com/anthonyhana04/Delta-Hacks-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	rateLimitSweepInterval = 5 * time.Minute
+	// lockoutDecay is how long a key must stay quiet before its backoff level resets
+	lockoutDecay = 24 * time.Hour
+)
+
+// RateLimitResult describes the state of a bucket after a request
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // only set when denied
+}
+
+// RateLimiter is a token bucket keyed by an arbitrary string
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error)
+	// AllowN takes cost tokens at once, e.g. bytes against a byte quota
+	AllowN(ctx context.Context, key string, cost, limit int, period time.Duration) (RateLimitResult, error)
+}
+
+// gcra applies a request costing cost tokens to a bucket using the generic
+// cell rate algorithm, which is equivalent to a token bucket refilled at
+// limit/period with a capacity of limit, but only needs the theoretical
+// arrival time (tat) stored. It returns the new tat to store (unchanged when
+// the request is denied).
+func gcra(tat, now time.Time, cost, limit int, period time.Duration) (time.Time, RateLimitResult) {
+	interval := period / time.Duration(limit)
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval * time.Duration(cost))
+	allowAt := newTAT.Add(-period)
+
+	res := RateLimitResult{Limit: limit}
+	if now.Before(allowAt) {
+		res.RetryAfter = allowAt.Sub(now)
+		res.ResetAfter = tat.Sub(now)
+		return tat, res
+	}
+	res.Allowed = true
+	res.Remaining = int((period - newTAT.Sub(now)) / interval)
+	res.ResetAfter = newTAT.Sub(now)
+	return newTAT, res
+}
+
+// MemoryRateLimiter keeps buckets in process. Limits are per instance.
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	l := &MemoryRateLimiter{buckets: make(map[string]time.Time)}
+	go l.sweepLoop()
+	return l
+}
+
+func (l *MemoryRateLimiter) Allow(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1, limit, period)
+}
+
+func (l *MemoryRateLimiter) AllowN(ctx context.Context, key string, cost, limit int, period time.Duration) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tat, res := gcra(l.buckets[key], time.Now(), cost, limit, period)
+	l.buckets[key] = tat
+	return res, nil
+}
+
+// sweepLoop drops buckets that have fully refilled
+func (l *MemoryRateLimiter) sweepLoop() {
+	for range time.Tick(rateLimitSweepInterval) {
+		now := time.Now()
+		l.mu.Lock()
+		for key, tat := range l.buckets {
+			if tat.Before(now) {
+				delete(l.buckets, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// PostgresRateLimiter shares buckets between instances through the
+// rate_limit_buckets table. Each request locks only its own row.
+type PostgresRateLimiter struct {
+	db *gorm.DB
+}
+
+func NewPostgresRateLimiter(db *gorm.DB) *PostgresRateLimiter {
+	l := &PostgresRateLimiter{db: db}
+	go l.sweepLoop()
+	return l
+}
+
+func (l *PostgresRateLimiter) Allow(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1, limit, period)
+}
+
+func (l *PostgresRateLimiter) AllowN(ctx context.Context, key string, cost, limit int, period time.Duration) (RateLimitResult, error) {
+	var res RateLimitResult
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		bucket := models.RateLimitBucket{Key: key, TAT: now, ExpiresAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bucket).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bucket, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		var tat time.Time
+		tat, res = gcra(bucket.TAT, now, cost, limit, period)
+		if !res.Allowed {
+			return nil
+		}
+		return tx.Model(&bucket).Updates(map[string]interface{}{"tat": tat, "expires_at": tat}).Error
+	})
+	return res, err
+}
+
+func (l *PostgresRateLimiter) sweepLoop() {
+	for range time.Tick(rateLimitSweepInterval) {
+		if err := l.db.Where("expires_at < ?", time.Now()).Delete(&models.RateLimitBucket{}).Error; err != nil {
+			log.Printf("Rate limit sweep failed: %v", err)
+		}
+	}
+}
+
+// LockoutStore tracks authentication failures and escalating lockouts
+type LockoutStore interface {
+	// LockedFor returns how long key remains locked (0 if not locked)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Fail records a failure and returns the lockout it triggered, if any
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	// Succeed clears the failure count. The backoff level is kept so an
+	// attacker can't reset it by interleaving successful logins.
+	Succeed(ctx context.Context, key string) error
+}
+
+// applyFailure updates s for a failure at now and returns the new lockout
+func applyFailure(s *models.AuthLockout, now time.Time, policy config.LockoutPolicy) time.Duration {
+	if now.Sub(s.LastFailureAt) > lockoutDecay {
+		s.Level = 0
+	}
+	if now.Sub(s.WindowStart) > policy.Window {
+		s.Failures = 0
+		s.WindowStart = now
+	}
+	s.Failures++
+	s.LastFailureAt = now
+	if s.Failures < policy.Threshold {
+		return 0
+	}
+
+	lock := policy.BaseLock
+	for i := 0; i < s.Level && lock < policy.MaxLock; i++ {
+		lock *= 2
+	}
+	lock = min(lock, policy.MaxLock)
+	s.LockedUntil = now.Add(lock)
+	s.Level++
+	s.Failures = 0
+	s.WindowStart = now
+	return lock
+}
+
+func lockRemaining(s *models.AuthLockout, now time.Time) time.Duration {
+	if now.Before(s.LockedUntil) {
+		return s.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// MemoryLockouts keeps lockout state in process
+type MemoryLockouts struct {
+	policy config.LockoutPolicy
+	mu     sync.Mutex
+	state  map[string]*models.AuthLockout
+}
+
+func NewMemoryLockouts(policy config.LockoutPolicy) *MemoryLockouts {
+	l := &MemoryLockouts{policy: policy, state: make(map[string]*models.AuthLockout)}
+	go l.sweepLoop()
+	return l
+}
+
+func (l *MemoryLockouts) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.state[key]; ok {
+		return lockRemaining(s, time.Now()), nil
+	}
+	return 0, nil
+}
+
+func (l *MemoryLockouts) Fail(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.state[key]
+	if !ok {
+		s = &models.AuthLockout{Key: key}
+		l.state[key] = s
+	}
+	return applyFailure(s, time.Now(), l.policy), nil
+}
+
+func (l *MemoryLockouts) Succeed(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.state[key]; ok {
+		s.Failures = 0
+	}
+	return nil
+}
+
+func (l *MemoryLockouts) sweepLoop() {
+	for range time.Tick(rateLimitSweepInterval) {
+		now := time.Now()
+		l.mu.Lock()
+		for key, s := range l.state {
+			if now.Sub(s.LastFailureAt) > lockoutDecay {
+				delete(l.state, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// PostgresLockouts shares lockout state between instances (auth_lockouts table)
+type PostgresLockouts struct {
+	policy config.LockoutPolicy
+	db     *gorm.DB
+}
+
+func NewPostgresLockouts(db *gorm.DB, policy config.LockoutPolicy) *PostgresLockouts {
+	l := &PostgresLockouts{policy: policy, db: db}
+	go l.sweepLoop()
+	return l
+}
+
+func (l *PostgresLockouts) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	var s models.AuthLockout
+	err := l.db.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&s).Error
+	if err != nil {
+		return 0, err
+	}
+	return lockRemaining(&s, time.Now()), nil
+}
+
+func (l *PostgresLockouts) Fail(ctx context.Context, key string) (time.Duration, error) {
+	var lock time.Duration
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		s := models.AuthLockout{Key: key, WindowStart: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&s).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, "key = ?", key).Error; err != nil {
+			return err
+		}
+		lock = applyFailure(&s, now, l.policy)
+		return tx.Save(&s).Error
+	})
+	return lock, err
+}
+
+func (l *PostgresLockouts) Succeed(ctx context.Context, key string) error {
+	return l.db.WithContext(ctx).Model(&models.AuthLockout{}).Where("key = ? AND failures > 0", key).Update("failures", 0).Error
+}
+
+func (l *PostgresLockouts) sweepLoop() {
+	for range time.Tick(rateLimitSweepInterval) {
+		if err := l.db.Where("last_failure_at < ?", time.Now().Add(-lockoutDecay)).Delete(&models.AuthLockout{}).Error; err != nil {
+			log.Printf("Lockout sweep failed: %v", err)
+		}
+	}
+}
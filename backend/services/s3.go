@@ -91,3 +91,40 @@ func (s *S3Service) GeneratePresignedGETURL(key string) (string, error) {
     })
     return req.Presign(15 * time.Minute)
 }
+
+// ListKeys returns every object key under prefix
+func (s *S3Service) ListKeys(prefix string) ([]string, error) {
+	var keys []string
+	err := s.S3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, *obj.Key)
+		}
+		return true
+	})
+	return keys, err
+}
+
+// DeleteObjects removes keys in batches of 1000 (the S3 limit per request)
+func (s *S3Service) DeleteObjects(keys []string) error {
+	for start := 0; start < len(keys); start += 1000 {
+		end := min(start+1000, len(keys))
+		objects := make([]*s3.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+		res, err := s.S3Client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(s.Bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(res.Errors) > 0 {
+			return fmt.Errorf("failed to delete %s: %s", aws.StringValue(res.Errors[0].Key), aws.StringValue(res.Errors[0].Message))
+		}
+	}
+	return nil
+}
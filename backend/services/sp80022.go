@@ -0,0 +1,329 @@
+package services
+
+import (
+	"fmt"
+	"math"
+)
+
+// SP80022Alpha is the significance level for the randomness tests
+const SP80022Alpha = 0.01
+
+// RandTestResult is the outcome of one NIST SP 800-22 test
+type RandTestResult struct {
+	Name    string    `json:"name"`
+	PValues []float64 `json:"p_values"`
+	Passed  bool      `json:"passed"`
+	Skipped string    `json:"skipped,omitempty"` // why the test couldn't run
+}
+
+// BytesToBits expands bytes into one bit per element, most significant first
+func BytesToBits(data []byte) []uint8 {
+	bits := make([]uint8, 0, len(data)*8)
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bits = append(bits, (b>>uint(i))&1)
+		}
+	}
+	return bits
+}
+
+// RunSP80022 runs the frequency, block frequency, runs, longest run, serial,
+// approximate entropy and cumulative sums tests from NIST SP 800-22 rev 1a
+func RunSP80022(bits []uint8) []RandTestResult {
+	n := len(bits)
+	log2n := int(math.Log2(float64(n)))
+	// Parameter choices follow the recommendations in SP 800-22 section 2
+	serialM := min(16, log2n-3)
+	apenM := min(10, log2n-6)
+
+	tests := []struct {
+		name string
+		run  func() ([]float64, error)
+	}{
+		{"frequency", func() ([]float64, error) { return FrequencyTest(bits) }},
+		{"block_frequency", func() ([]float64, error) { return BlockFrequencyTest(bits, 128) }},
+		{"runs", func() ([]float64, error) { return RunsTest(bits) }},
+		{"longest_run", func() ([]float64, error) { return LongestRunTest(bits) }},
+		{"serial", func() ([]float64, error) { return SerialTest(bits, serialM) }},
+		{"approximate_entropy", func() ([]float64, error) { return ApproximateEntropyTest(bits, apenM) }},
+		{"cumulative_sums", func() ([]float64, error) { return CumulativeSumsTest(bits) }},
+	}
+	results := make([]RandTestResult, 0, len(tests))
+	for _, t := range tests {
+		pValues, err := t.run()
+		results = append(results, newResult(t.name, pValues, err))
+	}
+	return results
+}
+
+func newResult(name string, pValues []float64, err error) RandTestResult {
+	r := RandTestResult{Name: name, PValues: pValues}
+	if err != nil {
+		r.Skipped = err.Error()
+		return r
+	}
+	r.Passed = true
+	for _, p := range pValues {
+		if p < SP80022Alpha {
+			r.Passed = false
+		}
+	}
+	return r
+}
+
+// FrequencyTest is the frequency (monobit) test (2.1)
+func FrequencyTest(bits []uint8) ([]float64, error) {
+	n := len(bits)
+	if n < 100 {
+		return nil, fmt.Errorf("needs at least 100 bits")
+	}
+	s := 0
+	for _, b := range bits {
+		s += 2*int(b) - 1
+	}
+	sObs := math.Abs(float64(s)) / math.Sqrt(float64(n))
+	return []float64{math.Erfc(sObs / math.Sqrt2)}, nil
+}
+
+// BlockFrequencyTest is the frequency test within a block (2.2)
+func BlockFrequencyTest(bits []uint8, m int) ([]float64, error) {
+	blocks := len(bits) / m
+	if len(bits) < 100 || blocks == 0 {
+		return nil, fmt.Errorf("needs at least 100 bits")
+	}
+	chi2 := 0.0
+	for i := 0; i < blocks; i++ {
+		ones := 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi2 += pi * pi
+	}
+	chi2 *= 4 * float64(m)
+	return []float64{igamc(float64(blocks)/2, chi2/2)}, nil
+}
+
+// RunsTest is the runs test (2.3)
+func RunsTest(bits []uint8) ([]float64, error) {
+	n := len(bits)
+	if n < 100 {
+		return nil, fmt.Errorf("needs at least 100 bits")
+	}
+	ones := 0
+	for _, b := range bits {
+		ones += int(b)
+	}
+	pi := float64(ones) / float64(n)
+	// The frequency prerequisite: too biased a sequence fails outright
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(float64(n)) {
+		return []float64{0}, nil
+	}
+	runs := 1
+	for i := 1; i < n; i++ {
+		if bits[i] != bits[i-1] {
+			runs++
+		}
+	}
+	num := math.Abs(float64(runs) - 2*float64(n)*pi*(1-pi))
+	den := 2 * math.Sqrt(2*float64(n)) * pi * (1 - pi)
+	return []float64{math.Erfc(num / den)}, nil
+}
+
+// LongestRunTest is the test for the longest run of ones in a block (2.4)
+func LongestRunTest(bits []uint8) ([]float64, error) {
+	n := len(bits)
+	var (
+		m      int
+		lo, hi int // run lengths of the first and last classes
+		pi     []float64
+	)
+	switch {
+	case n >= 750000:
+		m, lo, hi = 10000, 10, 16
+		pi = []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}
+	case n >= 6272:
+		m, lo, hi = 128, 4, 9
+		pi = []float64{0.1174, 0.2430, 0.2493, 0.1752, 0.1027, 0.1124}
+	case n >= 128:
+		m, lo, hi = 8, 1, 4
+		pi = []float64{0.2148, 0.3672, 0.2305, 0.1875}
+	default:
+		return nil, fmt.Errorf("needs at least 128 bits")
+	}
+
+	blocks := n / m
+	v := make([]int, len(pi))
+	for i := 0; i < blocks; i++ {
+		longest, run := 0, 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			if b == 1 {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+		v[min(max(longest, lo), hi)-lo]++
+	}
+
+	chi2 := 0.0
+	for i, p := range pi {
+		expected := float64(blocks) * p
+		d := float64(v[i]) - expected
+		chi2 += d * d / expected
+	}
+	return []float64{igamc(float64(len(pi)-1)/2, chi2/2)}, nil
+}
+
+// patternCounts counts the overlapping m-bit patterns, wrapping around the end
+func patternCounts(bits []uint8, m int) []int {
+	counts := make([]int, 1<<uint(m))
+	if m == 0 {
+		return counts
+	}
+	n := len(bits)
+	for i := 0; i < n; i++ {
+		pattern := 0
+		for j := 0; j < m; j++ {
+			pattern = pattern<<1 | int(bits[(i+j)%n])
+		}
+		counts[pattern]++
+	}
+	return counts
+}
+
+// SerialTest is the serial test (2.11) with block length m
+func SerialTest(bits []uint8, m int) ([]float64, error) {
+	if m < 2 {
+		return nil, fmt.Errorf("sequence too short")
+	}
+	n := float64(len(bits))
+	psi2 := func(m int) float64 {
+		if m <= 0 {
+			return 0
+		}
+		sum := 0.0
+		for _, c := range patternCounts(bits, m) {
+			sum += float64(c) * float64(c)
+		}
+		return sum*math.Pow(2, float64(m))/n - n
+	}
+	pm, pm1, pm2 := psi2(m), psi2(m-1), psi2(m-2)
+	del1 := pm - pm1
+	del2 := pm - 2*pm1 + pm2
+	return []float64{
+		igamc(math.Pow(2, float64(m-2)), del1/2),
+		igamc(math.Pow(2, float64(m-3)), del2/2),
+	}, nil
+}
+
+// ApproximateEntropyTest is the approximate entropy test (2.12) with block length m
+func ApproximateEntropyTest(bits []uint8, m int) ([]float64, error) {
+	if m < 1 {
+		return nil, fmt.Errorf("sequence too short")
+	}
+	n := float64(len(bits))
+	phi := func(m int) float64 {
+		sum := 0.0
+		for _, c := range patternCounts(bits, m) {
+			if c > 0 {
+				p := float64(c) / n
+				sum += p * math.Log(p)
+			}
+		}
+		return sum
+	}
+	apen := phi(m) - phi(m+1)
+	chi2 := 2 * n * (math.Ln2 - apen)
+	return []float64{igamc(math.Pow(2, float64(m-1)), chi2/2)}, nil
+}
+
+// CumulativeSumsTest is the cumulative sums test (2.13), forward then backward
+func CumulativeSumsTest(bits []uint8) ([]float64, error) {
+	n := len(bits)
+	if n < 100 {
+		return nil, fmt.Errorf("needs at least 100 bits")
+	}
+	pValue := func(reverse bool) float64 {
+		s, z := 0, 0
+		for i := range bits {
+			b := bits[i]
+			if reverse {
+				b = bits[n-1-i]
+			}
+			s += 2*int(b) - 1
+			z = max(z, abs(s))
+		}
+		sqrtN := math.Sqrt(float64(n))
+		sum1 := 0.0
+		for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+			sum1 += normalCDF(float64((4*k+1)*z)/sqrtN) - normalCDF(float64((4*k-1)*z)/sqrtN)
+		}
+		sum2 := 0.0
+		for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+			sum2 += normalCDF(float64((4*k+3)*z)/sqrtN) - normalCDF(float64((4*k+1)*z)/sqrtN)
+		}
+		return 1 - sum1 + sum2
+	}
+	return []float64{pValue(false), pValue(true)}, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// igamc is the regularized upper incomplete gamma function Q(a, x)
+func igamc(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 1
+	}
+	lnGammaA, _ := math.Lgamma(a)
+	prefix := a*math.Log(x) - x - lnGammaA
+
+	if x < a+1 {
+		// Series for P(a, x)
+		sum, term := 1/a, 1/a
+		for n := 1.0; n < 1000; n++ {
+			term *= x / (a + n)
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(prefix)
+	}
+
+	// Continued fraction for Q(a, x) (modified Lentz)
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1.0; i < 1000; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(prefix) * h
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+// nistEpsilon is the 100-bit sequence (the first bits of the binary
+// expansion of pi) used in the SP 800-22 worked examples
+const nistEpsilon = "1100100100001111110110101010001000100001011010001100001000110100110001001100011001100010100010111000"
+
+func bitString(t *testing.T, s string) []uint8 {
+	t.Helper()
+	bits := make([]uint8, len(s))
+	for i, c := range s {
+		switch c {
+		case '0':
+		case '1':
+			bits[i] = 1
+		default:
+			t.Fatalf("bad bit %q", c)
+		}
+	}
+	return bits
+}
+
+// The worked examples from section 2 of NIST SP 800-22 rev 1a. The
+// publication rounds p-values to six places; its longest run example prints
+// 0.180609, but its own chi-square of 4.882605 gives 0.180598, which is what
+// the reference implementation returns.
+func TestSP80022Examples(t *testing.T) {
+	tests := []struct {
+		name string
+		bits string
+		run  func([]uint8) ([]float64, error)
+		want []float64
+	}{
+		{"frequency (2.1.8)", nistEpsilon, FrequencyTest, []float64{0.109599}},
+		{"block frequency (2.2.8)", nistEpsilon,
+			func(b []uint8) ([]float64, error) { return BlockFrequencyTest(b, 10) }, []float64{0.706438}},
+		{"runs (2.3.8)", nistEpsilon, RunsTest, []float64{0.500798}},
+		{"longest run (2.4.8)",
+			"11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010",
+			LongestRunTest, []float64{0.180598}},
+		{"serial (2.11.4)", "0011011101",
+			func(b []uint8) ([]float64, error) { return SerialTest(b, 3) }, []float64{0.808792, 0.670320}},
+		{"approximate entropy (2.12.8)", nistEpsilon,
+			func(b []uint8) ([]float64, error) { return ApproximateEntropyTest(b, 2) }, []float64{0.235301}},
+		{"cumulative sums (2.13.8)", nistEpsilon, CumulativeSumsTest, []float64{0.219194, 0.114866}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run(bitString(t, tt.bits))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d p-values, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-6 {
+					t.Errorf("p-value %d = %.6f, want %.6f", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSP80022ShortInput(t *testing.T) {
+	short := make([]uint8, 99)
+	if _, err := FrequencyTest(short); err == nil {
+		t.Error("FrequencyTest accepted 99 bits")
+	}
+	if _, err := LongestRunTest(make([]uint8, 127)); err == nil {
+		t.Error("LongestRunTest accepted 127 bits")
+	}
+	// An all-zero sequence fails the runs prerequisite outright
+	if p, _ := RunsTest(make([]uint8, 100)); p[0] != 0 {
+		t.Errorf("RunsTest on zeros = %v, want 0", p[0])
+	}
+}
+
+func TestIgamc(t *testing.T) {
+	for _, x := range []float64{0.1, 0.5, 1, 2.5, 10, 40} {
+		// Q(1, x) = e^-x, Q(1/2, x) = erfc(sqrt(x)) and
+		// Q(3/2, x) = erfc(sqrt(x)) + 2 sqrt(x/pi) e^-x
+		if got, want := igamc(1, x), math.Exp(-x); math.Abs(got-want) > 1e-12 {
+			t.Errorf("igamc(1, %v) = %v, want %v", x, got, want)
+		}
+		if got, want := igamc(0.5, x), math.Erfc(math.Sqrt(x)); math.Abs(got-want) > 1e-12 {
+			t.Errorf("igamc(0.5, %v) = %v, want %v", x, got, want)
+		}
+		want := math.Erfc(math.Sqrt(x)) + 2*math.Sqrt(x/math.Pi)*math.Exp(-x)
+		if got := igamc(1.5, x); math.Abs(got-want) > 1e-12 {
+			t.Errorf("igamc(1.5, %v) = %v, want %v", x, got, want)
+		}
+	}
+	if igamc(3, 0) != 1 {
+		t.Error("igamc(a, 0) should be 1")
+	}
+}
+
+func TestBytesToBits(t *testing.T) {
+	got := BytesToBits([]byte{0xA5, 0x01})
+	want := bitString(t, "1010010100000001")
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("BytesToBits = %v, want %v (most significant bit first)", got, want)
+		}
+	}
+}
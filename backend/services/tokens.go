@@ -0,0 +1,113 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const tokenIssuer = "lavalock"
+
+var (
+	ErrTokenMalformed = errors.New("malformed token")
+	ErrTokenSignature = errors.New("invalid token signature")
+	ErrTokenExpired   = errors.New("token expired")
+)
+
+// AccessClaims are the claims carried by a bearer access token
+type AccessClaims struct {
+	Issuer    string    `json:"iss"`
+	Subject   uuid.UUID `json:"sub"`
+	SessionID uuid.UUID `json:"sid"`
+	Type      string    `json:"typ"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+// TokenService issues and verifies short-lived HS256 JWT access tokens.
+// The first key signs; all keys verify, which allows key rotation.
+type TokenService struct {
+	Keys       [][]byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+func NewTokenService(keys [][]byte, accessTTL, refreshTTL time.Duration) *TokenService {
+	return &TokenService{Keys: keys, AccessTTL: accessTTL, RefreshTTL: refreshTTL}
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueAccessToken returns a signed access token for the user's session
+func (s *TokenService) IssueAccessToken(userID, sessionID uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(s.AccessTTL)
+	claims := AccessClaims{
+		Issuer:    tokenIssuer,
+		Subject:   userID,
+		SessionID: sessionID,
+		Type:      "access",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: exp.Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig := sign(s.Keys[0], signingInput)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), exp, nil
+}
+
+// ParseAccessToken verifies the signature and expiry of an access token
+func (s *TokenService) ParseAccessToken(token string) (*AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrTokenMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	signingInput := parts[0] + "." + parts[1]
+	valid := false
+	for _, key := range s.Keys {
+		if hmac.Equal(sig, sign(key, signingInput)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrTokenSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var claims AccessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if claims.Issuer != tokenIssuer || claims.Type != "access" {
+		return nil, ErrTokenMalformed
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+func sign(key []byte, input string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
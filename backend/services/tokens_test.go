@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	s := NewTokenService([][]byte{[]byte("key-1")}, 15*time.Minute, time.Hour)
+	userID, sessionID := uuid.New(), uuid.New()
+
+	token, exp, err := s.IssueAccessToken(userID, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(exp); d <= 14*time.Minute || d > 15*time.Minute {
+		t.Errorf("token expires in %v, want 15m", d)
+	}
+	claims, err := s.ParseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != userID || claims.SessionID != sessionID || claims.ExpiresAt != exp.Unix() {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestAccessTokenKeyRotation(t *testing.T) {
+	oldKey, newKey := []byte("old-key"), []byte("new-key")
+	before := NewTokenService([][]byte{oldKey}, time.Minute, time.Hour)
+	during := NewTokenService([][]byte{newKey, oldKey}, time.Minute, time.Hour)
+	after := NewTokenService([][]byte{newKey}, time.Minute, time.Hour)
+
+	oldToken, _, _ := before.IssueAccessToken(uuid.New(), uuid.New())
+	newToken, _, _ := during.IssueAccessToken(uuid.New(), uuid.New())
+
+	if _, err := during.ParseAccessToken(oldToken); err != nil {
+		t.Errorf("token from the old key rejected during rotation: %v", err)
+	}
+	if _, err := after.ParseAccessToken(oldToken); err != ErrTokenSignature {
+		t.Errorf("token from a retired key: got %v, want ErrTokenSignature", err)
+	}
+	// New tokens are signed with the first key only
+	if _, err := after.ParseAccessToken(newToken); err != nil {
+		t.Errorf("token from the new key rejected: %v", err)
+	}
+	if _, err := before.ParseAccessToken(newToken); err != ErrTokenSignature {
+		t.Errorf("new token verified under the old key alone: %v", err)
+	}
+}
+
+func TestAccessTokenRejected(t *testing.T) {
+	s := NewTokenService([][]byte{[]byte("key-1")}, time.Minute, time.Hour)
+	token, _, _ := s.IssueAccessToken(uuid.New(), uuid.New())
+	parts := strings.Split(token, ".")
+
+	// signWith builds a validly signed token around arbitrary claims
+	signWith := func(claims AccessClaims) string {
+		payload, _ := json.Marshal(claims)
+		input := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+		return input + "." + base64.RawURLEncoding.EncodeToString(sign(s.Keys[0], input))
+	}
+	valid := AccessClaims{Issuer: tokenIssuer, Subject: uuid.New(), Type: "access", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+	refresh, foreign, expired := valid, valid, valid
+	refresh.Type = "refresh"
+	foreign.Issuer = "someone-else"
+	expired.ExpiresAt = time.Now().Add(-time.Second).Unix()
+
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	otherClaims, _ := json.Marshal(valid)
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"empty", "", ErrTokenMalformed},
+		{"two parts", parts[0] + "." + parts[1], ErrTokenMalformed},
+		{"alg none", noneHeader + "." + parts[1] + ".", ErrTokenMalformed},
+		{"bad signature encoding", parts[0] + "." + parts[1] + ".!!", ErrTokenMalformed},
+		{"swapped payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString(otherClaims) + "." + parts[2], ErrTokenSignature},
+		{"truncated signature", token[:len(token)-3], ErrTokenSignature},
+		{"refresh type", signWith(refresh), ErrTokenMalformed},
+		{"foreign issuer", signWith(foreign), ErrTokenMalformed},
+		{"expired", signWith(expired), ErrTokenExpired},
+	}
+	for _, tt := range tests {
+		if _, err := s.ParseAccessToken(tt.token); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if _, err := s.ParseAccessToken(signWith(valid)); err != nil {
+		t.Errorf("hand-built valid token rejected: %v", err)
+	}
+}
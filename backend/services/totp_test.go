@@ -0,0 +1,85 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+var rfcSecret = []byte("12345678901234567890")
+
+// RFC 4226 appendix D
+func TestHOTPVectors(t *testing.T) {
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := TOTPCode(rfcSecret, int64(counter)); got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// RFC 6238 appendix B (SHA-1). The RFC lists eight digits; ours are the
+// last six of them.
+func TestTOTPVectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		step := TOTPStep(time.Unix(tt.unix, 0))
+		if got := TOTPCode(rfcSecret, step); got != tt.want {
+			t.Errorf("T=%d: got %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+
+	for delta := int64(-1); delta <= 1; delta++ {
+		matched, ok := ValidateTOTP(rfcSecret, TOTPCode(rfcSecret, step+delta), now, 0)
+		if !ok || matched != step+delta {
+			t.Errorf("code from step %+d: got step %d, %v", delta, matched, ok)
+		}
+	}
+	for _, delta := range []int64{-2, 2} {
+		if _, ok := ValidateTOTP(rfcSecret, TOTPCode(rfcSecret, step+delta), now, 0); ok {
+			t.Errorf("code from step %+d accepted outside the skew", delta)
+		}
+	}
+
+	// Replays of the last used step, or earlier ones, are refused
+	code := TOTPCode(rfcSecret, step)
+	if _, ok := ValidateTOTP(rfcSecret, code, now, step); ok {
+		t.Error("code accepted again after its step was used")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, TOTPCode(rfcSecret, step+1), now, step); !ok {
+		t.Error("next step's code refused after the current step was used")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	if got := EncodeTOTPSecret(rfcSecret); got != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Errorf("EncodeTOTPSecret = %s", got)
+	}
+	u, err := url.Parse(TOTPURI("LavaLock", "user@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/LavaLock:user@example.com" {
+		t.Errorf("URI = %s", u)
+	}
+	if q.Get("secret") != EncodeTOTPSecret(rfcSecret) || q.Get("issuer") != "LavaLock" ||
+		q.Get("digits") != "6" || q.Get("period") != "30" || q.Get("algorithm") != "SHA1" {
+		t.Errorf("query = %v", q)
+	}
+}